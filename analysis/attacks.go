@@ -0,0 +1,125 @@
+package analysis
+
+import "github.com/mholt/chessml/chess"
+
+// Attacks returns the squares attacked by the piece at row,col:
+// those it could capture on if an enemy piece were there. Unlike
+// the moves from chess.PossibleMoves, this includes squares held
+// by pieces of its own color, which it defends, and leaves out
+// pawn moves straight ahead, which can't capture.
+func Attacks(b chess.Board, row, col int) []chess.Coord {
+	p := b.Spaces[row][col]
+	var squares []chess.Coord
+
+	add := func(r, c int) bool {
+		if r < 0 || r >= chess.Size || c < 0 || c >= chess.Size {
+			return false
+		}
+		squares = append(squares, chess.Coord{Row: r, Col: c})
+		return true
+	}
+	ray := func(dr, dc int) {
+		for r, c := row+dr, col+dc; add(r, c); r, c = r+dr, c+dc {
+			if b.Spaces[r][c].Rank != chess.Empty {
+				break
+			}
+		}
+	}
+
+	switch p.Rank {
+	case chess.Pawn:
+		forward := pawnDirection(p.Color)
+		add(row+forward, col-1)
+		add(row+forward, col+1)
+	case chess.Knight:
+		for _, d := range knightJumps {
+			add(row+d[0], col+d[1])
+		}
+	case chess.King:
+		for _, d := range kingSteps {
+			add(row+d[0], col+d[1])
+		}
+	case chess.Bishop:
+		for _, d := range kingSteps[4:] {
+			ray(d[0], d[1])
+		}
+	case chess.Rook:
+		for _, d := range kingSteps[:4] {
+			ray(d[0], d[1])
+		}
+	case chess.Queen:
+		for _, d := range kingSteps {
+			ray(d[0], d[1])
+		}
+	}
+
+	return squares
+}
+
+// Attackers returns the squares of player's pieces that attack
+// the square at row,col.
+func Attackers(b chess.Board, row, col int, player chess.Color) []chess.Coord {
+	var attackers []chess.Coord
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			if b.Spaces[r][c].Rank == chess.Empty || b.Spaces[r][c].Color != player {
+				continue
+			}
+			for _, sq := range Attacks(b, r, c) {
+				if sq.Row == row && sq.Col == col {
+					attackers = append(attackers, chess.Coord{Row: r, Col: c})
+					break
+				}
+			}
+		}
+	}
+	return attackers
+}
+
+// AttackMap returns how many of player's pieces attack each
+// square of the board, indexed like Board.Spaces.
+func AttackMap(b chess.Board, player chess.Color) [chess.Size][chess.Size]int {
+	var m [chess.Size][chess.Size]int
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			if b.Spaces[r][c].Rank == chess.Empty || b.Spaces[r][c].Color != player {
+				continue
+			}
+			for _, sq := range Attacks(b, r, c) {
+				m[sq.Row][sq.Col]++
+			}
+		}
+	}
+	return m
+}
+
+// findKing returns the square of player's king, and false
+// if player has no king on the board.
+func findKing(b chess.Board, player chess.Color) (chess.Coord, bool) {
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			if b.Spaces[r][c].Rank == chess.King && b.Spaces[r][c].Color == player {
+				return chess.Coord{Row: r, Col: c}, true
+			}
+		}
+	}
+	return chess.Coord{}, false
+}
+
+// opponent returns the other color.
+func opponent(player chess.Color) chess.Color {
+	if player == chess.WhiteTeam {
+		return chess.BlackTeam
+	}
+	return chess.WhiteTeam
+}
+
+var (
+	// kingSteps are the directions a king moves in: the four
+	// straight ones first, as a rook moves, then the four
+	// diagonal ones, as a bishop moves.
+	kingSteps = [8][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+	// knightJumps are the ways a knight moves.
+	knightJumps = [8][2]int{{2, 1}, {2, -1}, {-2, 1}, {-2, -1}, {1, 2}, {1, -2}, {-1, 2}, {-1, -2}}
+)
@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"errors"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// Development describes how far one color has developed its
+// pieces, from the moves executed so far.
+type Development struct {
+	MinorsDeveloped int  // knights and bishops off their starting squares
+	RooksConnected  bool // whether two rooks defend each other with nothing between them
+	EarlyQueen      bool // whether the queen moved while two or more knights and bishops were undeveloped
+	Tempo           int  // moves spent developing; see DevelopmentOf
+}
+
+// DevelopmentOf computes the development of player's pieces. The
+// tempo count is the number of player's moves that moved a piece
+// from its starting square for the first time, counting only
+// knights, bishops, rooks, the queen, the d and e pawns, and the
+// king when castling. Moving a piece again doesn't count.
+func DevelopmentOf(game chess.Game, player chess.Color) Development {
+	b := game.Board
+	var dev Development
+
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			p := b.Spaces[r][c]
+			if p.Color != player {
+				continue
+			}
+			switch p.Rank {
+			case chess.Knight, chess.Bishop:
+				dev.MinorsDeveloped++
+			case chess.Rook:
+				for _, sq := range Attacks(b, r, c) {
+					if q := b.Spaces[sq.Row][sq.Col]; q.Rank == chess.Rook && q.Color == player {
+						dev.RooksConnected = true
+					}
+				}
+			}
+		}
+	}
+	dev.MinorsDeveloped -= undevelopedMinors(b, player)
+
+	// Replay the moves so far to see which pieces moved when
+	start, err := game.StartPosition()
+	if err != nil {
+		return dev
+	}
+	var original [chess.Size][chess.Size]bool // whether the piece there hasn't moved
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			p := start.Board.Spaces[r][c]
+			original[r][c] = p.Rank != chess.Empty && p.Color == player
+		}
+	}
+
+	game.Replay(func(pos chess.Position, m chess.PlayedMove) error {
+		if m.Ply >= game.Ply() {
+			return errDevelopmentDone
+		}
+
+		from := pos.Board.Spaces[m.From.Row][m.From.Col]
+		if pos.ToMove == player {
+			if original[m.From.Row][m.From.Col] {
+				switch from.Rank {
+				case chess.Knight, chess.Bishop, chess.Rook, chess.Queen:
+					dev.Tempo++
+				case chess.Pawn:
+					if m.From.Col == 3 || m.From.Col == 4 {
+						dev.Tempo++
+					}
+				case chess.King:
+					if m.Castle != "" {
+						dev.Tempo++
+					}
+				}
+			}
+			if from.Rank == chess.Queen && undevelopedMinors(pos.Board, player) >= 2 {
+				dev.EarlyQueen = true
+			}
+			switch m.Castle {
+			case chess.KingsideCastle:
+				original[m.From.Row][chess.Size-1] = false
+			case chess.QueensideCastle:
+				original[m.From.Row][0] = false
+			}
+		} else if m.EnPassant {
+			original[m.From.Row][m.To.Col] = false
+		}
+
+		original[m.From.Row][m.From.Col] = false
+		original[m.To.Row][m.To.Col] = false
+		return nil
+	})
+
+	return dev
+}
+
+// CenterControl counts player's attacks on the four center squares,
+// d4, e4, d5 and e5. Each piece attacking a square counts once,
+// including pawns attacking empty squares, and pieces defending
+// one of player's own pieces there.
+func CenterControl(game chess.Game, player chess.Color) float64 {
+	return attacksIn(game.Board, player, 3, 4)
+}
+
+// ExtendedCenterControl is like CenterControl, but for the sixteen
+// squares from c3 to f6.
+func ExtendedCenterControl(game chess.Game, player chess.Color) float64 {
+	return attacksIn(game.Board, player, 2, 5)
+}
+
+// attacksIn counts player's attacks on the squares in the rows
+// and columns from lo to hi.
+func attacksIn(b chess.Board, player chess.Color, lo, hi int) float64 {
+	m := AttackMap(b, player)
+	total := 0
+	for r := lo; r <= hi; r++ {
+		for c := lo; c <= hi; c++ {
+			total += m[r][c]
+		}
+	}
+	return float64(total)
+}
+
+// DevelopmentFeatures makes a feature for each value of
+// Development, per color, computing DevelopmentOf once for all
+// of them, followed by CenterControl and ExtendedCenterControl.
+func DevelopmentFeatures() []Feature {
+	features := PerColorGroup([]Column{
+		{"minors-developed", Integer},
+		{"rooks-connected", Boolean},
+		{"early-queen", Boolean},
+		{"tempo", Integer},
+	}, func(game chess.Game, player chess.Color) []float64 {
+		dev := DevelopmentOf(game, player)
+		return []float64{
+			float64(dev.MinorsDeveloped),
+			boolValue(dev.RooksConnected),
+			boolValue(dev.EarlyQueen),
+			float64(dev.Tempo),
+		}
+	})
+	features = append(features, PerColor("center-control", Integer, CenterControl)...)
+	return append(features, PerColor("extended-center-control", Integer, ExtendedCenterControl)...)
+}
+
+// errDevelopmentDone stops replaying a game at the current move.
+var errDevelopmentDone = errors.New("Reached the current move")
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestDevelopmentOf(t *testing.T) {
+	// White brings the queen out before the minor pieces, and
+	// spends a second move on it
+	game := movesGame(t, "e4 e5 Qh5 Nc6 Bc4 g6 Qf3 Nf6")
+	want := Development{MinorsDeveloped: 1, EarlyQueen: true, Tempo: 3}
+	if got := DevelopmentOf(game, chess.WhiteTeam); got != want {
+		t.Errorf("Got White %+v, want %+v", got, want)
+	}
+	want = Development{MinorsDeveloped: 2, Tempo: 3}
+	if got := DevelopmentOf(game, chess.BlackTeam); got != want {
+		t.Errorf("Got Black %+v, want %+v", got, want)
+	}
+
+	// Only the moves executed so far count
+	if err := game.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.Execute(2); err != nil {
+		t.Fatal(err)
+	}
+	want = Development{Tempo: 1}
+	if got := DevelopmentOf(game, chess.WhiteTeam); got != want {
+		t.Errorf("After 2 moves, got White %+v, want %+v", got, want)
+	}
+
+	game = positionGame(t, "r3k2r/8/8/8/8/8/8/R4RK1 w kq - 0 1")
+	if !DevelopmentOf(game, chess.WhiteTeam).RooksConnected {
+		t.Errorf("White's rooks on a1 and f1 aren't connected")
+	}
+	if DevelopmentOf(game, chess.BlackTeam).RooksConnected {
+		t.Errorf("Black's rooks are connected through the king")
+	}
+}
+
+func TestCenterControl(t *testing.T) {
+	game := positionGame(t, chess.StartFEN)
+	if got := CenterControl(game, chess.WhiteTeam); got != 0 {
+		t.Errorf("Got center control %g at the start, want 0", got)
+	}
+	// The pawns attack the third rank twice over, and the
+	// knights c3 and f3 once more
+	if got := ExtendedCenterControl(game, chess.WhiteTeam); got != 10 {
+		t.Errorf("Got extended center control %g at the start, want 10", got)
+	}
+
+	game = movesGame(t, "e4 d5")
+	if got := CenterControl(game, chess.WhiteTeam); got != 1 {
+		t.Errorf("Got White's center control %g after 1. e4 d5, want 1", got)
+	}
+	if got := CenterControl(game, chess.BlackTeam); got != 2 {
+		t.Errorf("Got Black's center control %g after 1. e4 d5, want 2", got)
+	}
+}
@@ -0,0 +1,204 @@
+package analysis
+
+import (
+	"sync"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// A Feature is one value computed from a game as it currently
+// stands, with the moves executed so far. Each feature is one
+// column of a dataset.
+type Feature interface {
+	// Name is the feature's name, like "material". It must
+	// be unique among the registered features.
+	Name() string
+
+	// Type is the kind of value the feature has.
+	Type() FeatureType
+
+	// Compute returns the feature's value for the game.
+	Compute(game chess.Game) float64
+}
+
+// FeatureType is the kind of value a feature has.
+type FeatureType int
+
+const (
+	Real    FeatureType = iota // any number
+	Integer                    // a whole number, like a count
+	Boolean                    // 1 for true or 0 for false
+)
+
+// ColorFunc computes a value for one color, like Material.
+type ColorFunc func(game chess.Game, player chess.Color) float64
+
+// Ratio makes a feature that is the ratio of White's value
+// to Black's, with one added to both to avoid dividing by zero.
+func Ratio(name string, fn ColorFunc) Feature {
+	return funcFeature{name, Real, func(game chess.Game) float64 {
+		return (fn(game, chess.WhiteTeam) + 1) / (fn(game, chess.BlackTeam) + 1)
+	}}
+}
+
+// PerColor makes two features from fn, one with White's value
+// and one with Black's, named name-white and name-black.
+func PerColor(name string, typ FeatureType, fn ColorFunc) []Feature {
+	return []Feature{
+		funcFeature{name + "-white", typ, func(game chess.Game) float64 {
+			return fn(game, chess.WhiteTeam)
+		}},
+		funcFeature{name + "-black", typ, func(game chess.Game) float64 {
+			return fn(game, chess.BlackTeam)
+		}},
+	}
+}
+
+// PerPosition makes a feature from a function of the whole
+// position rather than of one color.
+func PerPosition(name string, typ FeatureType, fn func(game chess.Game) float64) Feature {
+	return funcFeature{name, typ, fn}
+}
+
+// funcFeature is a feature computed by a function.
+type funcFeature struct {
+	name string
+	typ  FeatureType
+	fn   func(chess.Game) float64
+}
+
+func (f funcFeature) Name() string                    { return f.name }
+func (f funcFeature) Type() FeatureType               { return f.typ }
+func (f funcFeature) Compute(game chess.Game) float64 { return f.fn(game) }
+
+// A Column is the name and type of one of the values of a group
+// of features; see PerColorGroup.
+type Column struct {
+	Name string
+	Type FeatureType
+}
+
+// PerColorGroup is like PerColor, but makes features from several
+// values that are computed together, like the fields of a struct:
+// fn returns one value for each of columns, in the same order.
+// ComputeFeatures calls fn only once per color for all of them.
+func PerColorGroup(columns []Column, fn func(game chess.Game, player chess.Color) []float64) []Feature {
+	white := &featureGroup{func(game chess.Game) []float64 { return fn(game, chess.WhiteTeam) }}
+	black := &featureGroup{func(game chess.Game) []float64 { return fn(game, chess.BlackTeam) }}
+
+	var features []Feature
+	for i, col := range columns {
+		features = append(features,
+			groupFeature{col.Name + "-white", col.Type, white, i},
+			groupFeature{col.Name + "-black", col.Type, black, i},
+		)
+	}
+	return features
+}
+
+// featureGroup computes the values of several features at once.
+type featureGroup struct {
+	fn func(chess.Game) []float64
+}
+
+// groupFeature is one of the values of a featureGroup.
+type groupFeature struct {
+	name  string
+	typ   FeatureType
+	group *featureGroup
+	index int
+}
+
+func (f groupFeature) Name() string                    { return f.name }
+func (f groupFeature) Type() FeatureType               { return f.typ }
+func (f groupFeature) Compute(game chess.Game) float64 { return f.group.fn(game)[f.index] }
+
+// ComputeFeatures computes the features of the game as it stands,
+// in order. Features made together by PerColorGroup share the work
+// of computing their values, where calling Compute on each would
+// repeat it.
+func ComputeFeatures(game chess.Game, features []Feature) []float64 {
+	values := make([]float64, len(features))
+	groups := make(map[*featureGroup][]float64)
+	for i, f := range features {
+		gf, ok := f.(groupFeature)
+		if !ok {
+			values[i] = f.Compute(game)
+			continue
+		}
+		v, ok := groups[gf.group]
+		if !ok {
+			v = gf.group.fn(game)
+			groups[gf.group] = v
+		}
+		values[i] = v[gf.index]
+	}
+	return values
+}
+
+// Register adds features to the registry, after the ones
+// already there. Datasets have a column for each registered
+// feature, in the order they were registered. It panics if
+// a feature's name is already registered.
+//
+// Only material, attack-value, mobility and space are registered
+// by default. The others are registered by passing them here, as
+// from PawnFeatures, KingFeatures, PieceSquareFeatures,
+// PhaseFeature, DevelopmentFeatures, ThreatFeatures and
+// MotifFeatures.
+func Register(features ...Feature) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, f := range features {
+		for _, r := range registry {
+			if r.Name() == f.Name() {
+				panic("analysis: feature " + f.Name() + " is already registered")
+			}
+		}
+		registry = append(registry, f)
+	}
+}
+
+// Features returns the registered features, in the order
+// they were registered.
+func Features() []Feature {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Feature(nil), registry...)
+}
+
+// LookupFeature returns the registered feature with the
+// given name, or nil if there isn't one.
+func LookupFeature(name string) Feature {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, f := range registry {
+		if f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+var (
+	registry   []Feature
+	registryMu sync.RWMutex
+)
+
+func init() {
+	Register(
+		Ratio("material", Material),
+		Ratio("attack-value", AttackValue),
+		Ratio("mobility", Mobility),
+		Ratio("space", Space),
+	)
+}
+
+// boolValue returns the value of a Boolean feature.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
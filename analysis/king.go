@@ -0,0 +1,125 @@
+package analysis
+
+import "github.com/mholt/chessml/chess"
+
+// KingSafety describes how exposed one color's king is.
+type KingSafety struct {
+	PawnShield    int     // pawns of the king's color one or two ranks in front of it, on its own file or those beside it
+	OpenFiles     int     // files on or beside the king's with no pawns
+	HalfOpenFiles int     // files on or beside the king's with only enemy pawns
+	Attackers     int     // enemy pieces attacking the king or a square next to it
+	AttackWeight  float64 // sum of the PointValues of those pieces
+	EscapeSquares int     // squares next to the king it could move to without being attacked
+	Castled       bool    // whether the player has castled in the moves executed so far
+}
+
+// King computes the safety of player's king. If player has
+// no king on the board, only Castled is set.
+func King(game chess.Game, player chess.Color) KingSafety {
+	b := game.Board
+	enemy := opponent(player)
+
+	var ks KingSafety
+
+	for _, m := range game.Moves[:game.Ply()] {
+		if m.PlayerColor != player {
+			continue
+		}
+		if pm, err := m.Parse(); err == nil && pm.Castle != "" {
+			ks.Castled = true
+			break
+		}
+	}
+
+	king, ok := findKing(b, player)
+	if !ok {
+		return ks
+	}
+
+	forward := pawnDirection(player)
+	for c := king.Col - 1; c <= king.Col+1; c++ {
+		if c < 0 || c >= chess.Size {
+			continue
+		}
+
+		for _, r := range []int{king.Row + forward, king.Row + 2*forward} {
+			if isPawn(b, r, c, player) {
+				ks.PawnShield++
+			}
+		}
+
+		own, enemies := false, false
+		for r := 0; r < chess.Size; r++ {
+			own = own || isPawn(b, r, c, player)
+			enemies = enemies || isPawn(b, r, c, enemy)
+		}
+		if !own && !enemies {
+			ks.OpenFiles++
+		} else if !own {
+			ks.HalfOpenFiles++
+		}
+	}
+
+	// The king zone is the king's square and those next to it
+	inZone := func(sq chess.Coord) bool {
+		dr, dc := sq.Row-king.Row, sq.Col-king.Col
+		return dr >= -1 && dr <= 1 && dc >= -1 && dc <= 1
+	}
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			if b.Spaces[r][c].Rank == chess.Empty || b.Spaces[r][c].Color != enemy {
+				continue
+			}
+			for _, sq := range Attacks(b, r, c) {
+				if inZone(sq) {
+					ks.Attackers++
+					ks.AttackWeight += PointValue(b.Spaces[r][c])
+					break
+				}
+			}
+		}
+	}
+
+	// Take the king off the board so that squares behind it
+	// on the line of an attacking piece count as attacked
+	without := b
+	without.Spaces[king.Row][king.Col] = chess.Piece{}
+	attacked := AttackMap(without, enemy)
+	for _, d := range kingSteps {
+		r, c := king.Row+d[0], king.Col+d[1]
+		if r < 0 || r >= chess.Size || c < 0 || c >= chess.Size {
+			continue
+		}
+		p := b.Spaces[r][c]
+		if (p.Rank == chess.Empty || p.Color != player) && attacked[r][c] == 0 {
+			ks.EscapeSquares++
+		}
+	}
+
+	return ks
+}
+
+// KingFeatures makes a feature for each value of KingSafety,
+// per color, computing King once for all of them.
+func KingFeatures() []Feature {
+	return PerColorGroup([]Column{
+		{"king-pawn-shield", Integer},
+		{"king-open-files", Integer},
+		{"king-half-open-files", Integer},
+		{"king-attackers", Integer},
+		{"king-attack-weight", Real},
+		{"king-escape-squares", Integer},
+		{"castled", Boolean},
+	}, func(game chess.Game, player chess.Color) []float64 {
+		ks := King(game, player)
+		return []float64{
+			float64(ks.PawnShield),
+			float64(ks.OpenFiles),
+			float64(ks.HalfOpenFiles),
+			float64(ks.Attackers),
+			ks.AttackWeight,
+			float64(ks.EscapeSquares),
+			boolValue(ks.Castled),
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestKing(t *testing.T) {
+	// White castles with zeros, and Black's bishop eyes f2
+	game := movesGame(t, "e4 e5 Nf3 Nc6 Bc4 Bc5 0-0")
+	want := KingSafety{PawnShield: 3, Attackers: 1, AttackWeight: 3.33, EscapeSquares: 1, Castled: true}
+	if got := King(game, chess.WhiteTeam); got != want {
+		t.Errorf("Got White %+v, want %+v", got, want)
+	}
+	want = KingSafety{PawnShield: 2, Attackers: 1, AttackWeight: 3.33, EscapeSquares: 2}
+	if got := King(game, chess.BlackTeam); got != want {
+		t.Errorf("Got Black %+v, want %+v", got, want)
+	}
+
+	// A rook on the back rank leaves Black's king nowhere to go
+	game = positionGame(t, "3R2k1/5ppp/8/8/8/8/8/6K1 b - - 0 1")
+	want = KingSafety{HalfOpenFiles: 3, EscapeSquares: 5}
+	if got := King(game, chess.WhiteTeam); got != want {
+		t.Errorf("Got White %+v, want %+v", got, want)
+	}
+	want = KingSafety{PawnShield: 3, Attackers: 1, AttackWeight: 5.1}
+	if got := King(game, chess.BlackTeam); got != want {
+		t.Errorf("Got Black %+v, want %+v", got, want)
+	}
+}
+
+// movesGame makes a game of the moves, in SAN separated by
+// spaces, from the standard starting position, and plays them.
+func movesGame(t *testing.T, moves string) chess.Game {
+	t.Helper()
+	game := chess.Game{Tags: make(map[string]string)}
+	for i, text := range strings.Fields(moves) {
+		player, color := chess.White, chess.WhiteTeam
+		if i%2 == 1 {
+			player, color = chess.Black, chess.BlackTeam
+		}
+		game.Moves = append(game.Moves, chess.Move{Player: player, PlayerColor: color, Number: i/2 + 1, Text: text})
+	}
+	if err := game.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.Execute(-1); err != nil {
+		t.Fatalf("%s: %v", moves, err)
+	}
+	return game
+}
@@ -0,0 +1,208 @@
+package analysis
+
+import "github.com/mholt/chessml/chess"
+
+// PawnStructure counts the features of one color's pawns.
+type PawnStructure struct {
+	Doubled         int // pawns on a file behind another of the same color
+	Isolated        int // pawns with no pawns of the same color on the files beside them
+	Backward        int // pawns behind those on the files beside them, which can't safely advance
+	Passed          int // pawns with no enemy pawns in front of them on their own or the files beside them
+	ProtectedPassed int // passed pawns defended by a pawn
+	ConnectedPassed int // passed pawns with another passed pawn beside or diagonally next to them
+	Islands         int // groups of pawns on adjacent files
+	Chains          int // groups of two or more pawns defending each other diagonally
+	AdvancedRanks   int // sum of how many ranks each pawn has advanced from its starting rank
+}
+
+// Pawns computes the pawn structure of player's pawns.
+func Pawns(game chess.Game, player chess.Color) PawnStructure {
+	b := game.Board
+	enemy := opponent(player)
+	forward := pawnDirection(player)
+
+	var ps PawnStructure
+
+	// Count pawns on each file, and find the chains
+	var files [chess.Size]int
+	for c := 0; c < chess.Size; c++ {
+		for r := 0; r < chess.Size; r++ {
+			if isPawn(b, r, c, player) {
+				files[c]++
+			}
+		}
+	}
+	for c := 0; c < chess.Size; c++ {
+		if files[c] > 1 {
+			ps.Doubled += files[c] - 1
+		}
+		if files[c] > 0 && (c == 0 || files[c-1] == 0) {
+			ps.Islands++
+		}
+	}
+	ps.Chains = pawnChains(b, player)
+
+	passed := func(r, c int) bool {
+		for f := c - 1; f <= c+1; f++ {
+			for rr := r + forward; rr >= 0 && rr < chess.Size; rr += forward {
+				if isPawn(b, rr, f, enemy) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	for c := 0; c < chess.Size; c++ {
+		for r := 0; r < chess.Size; r++ {
+			if !isPawn(b, r, c, player) {
+				continue
+			}
+
+			ps.AdvancedRanks += relativeRank(r, player) - 1
+
+			isolated := (c == 0 || files[c-1] == 0) && (c == chess.Size-1 || files[c+1] == 0)
+			if isolated {
+				ps.Isolated++
+			} else if isBackward(b, r, c, player) {
+				ps.Backward++
+			}
+
+			if !passed(r, c) {
+				continue
+			}
+			ps.Passed++
+			if isPawn(b, r-forward, c-1, player) || isPawn(b, r-forward, c+1, player) {
+				ps.ProtectedPassed++
+			}
+			for _, f := range []int{c - 1, c + 1} {
+				connected := false
+				for rr := r - 1; rr <= r+1; rr++ {
+					if isPawn(b, rr, f, player) && passed(rr, f) {
+						connected = true
+					}
+				}
+				if connected {
+					ps.ConnectedPassed++
+					break
+				}
+			}
+		}
+	}
+
+	return ps
+}
+
+// isBackward returns whether the pawn at r,c is backward: every
+// pawn of its color on the files beside it is further advanced,
+// and the square in front of it is attacked by an enemy pawn.
+func isBackward(b chess.Board, r, c int, player chess.Color) bool {
+	forward := pawnDirection(player)
+	rank := relativeRank(r, player)
+
+	for _, f := range []int{c - 1, c + 1} {
+		for rr := 0; rr < chess.Size; rr++ {
+			if isPawn(b, rr, f, player) && relativeRank(rr, player) <= rank {
+				return false
+			}
+		}
+	}
+
+	enemy := opponent(player)
+	stop := r + forward
+	return isPawn(b, stop+forward, c-1, enemy) || isPawn(b, stop+forward, c+1, enemy)
+}
+
+// pawnChains counts the groups of two or more of player's pawns
+// that are linked by defending each other diagonally.
+func pawnChains(b chess.Board, player chess.Color) int {
+	var seen [chess.Size][chess.Size]bool
+	chains := 0
+
+	for c := 0; c < chess.Size; c++ {
+		for r := 0; r < chess.Size; r++ {
+			if seen[r][c] || !isPawn(b, r, c, player) {
+				continue
+			}
+
+			// Walk the chain from here, counting its pawns
+			size := 0
+			stack := []chess.Coord{{Row: r, Col: c}}
+			seen[r][c] = true
+			for len(stack) > 0 {
+				sq := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				size++
+				for _, d := range [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}} {
+					rr, cc := sq.Row+d[0], sq.Col+d[1]
+					if isPawn(b, rr, cc, player) && !seen[rr][cc] {
+						seen[rr][cc] = true
+						stack = append(stack, chess.Coord{Row: rr, Col: cc})
+					}
+				}
+			}
+			if size > 1 {
+				chains++
+			}
+		}
+	}
+
+	return chains
+}
+
+// isPawn returns whether there is a pawn of color c at row,col,
+// which may be off the board.
+func isPawn(b chess.Board, row, col int, c chess.Color) bool {
+	if row < 0 || row >= chess.Size || col < 0 || col >= chess.Size {
+		return false
+	}
+	p := b.Spaces[row][col]
+	return p.Rank == chess.Pawn && p.Color == c
+}
+
+// pawnDirection returns the way player's pawns move along
+// the rows: 1 for White and -1 for Black.
+func pawnDirection(player chess.Color) int {
+	if player == chess.WhiteTeam {
+		return 1
+	}
+	return -1
+}
+
+// relativeRank returns the rank of row from player's side of
+// the board, where 0 is the player's back rank.
+func relativeRank(row int, player chess.Color) int {
+	if player == chess.WhiteTeam {
+		return row
+	}
+	return chess.Size - 1 - row
+}
+
+// PawnFeatures makes a feature for each count of PawnStructure,
+// per color, computing Pawns once for all of them.
+func PawnFeatures() []Feature {
+	return PerColorGroup([]Column{
+		{"doubled-pawns", Integer},
+		{"isolated-pawns", Integer},
+		{"backward-pawns", Integer},
+		{"passed-pawns", Integer},
+		{"protected-passed-pawns", Integer},
+		{"connected-passed-pawns", Integer},
+		{"pawn-islands", Integer},
+		{"pawn-chains", Integer},
+		{"pawn-advancement", Integer},
+	}, func(game chess.Game, player chess.Color) []float64 {
+		ps := Pawns(game, player)
+		return []float64{
+			float64(ps.Doubled),
+			float64(ps.Isolated),
+			float64(ps.Backward),
+			float64(ps.Passed),
+			float64(ps.ProtectedPassed),
+			float64(ps.ConnectedPassed),
+			float64(ps.Islands),
+			float64(ps.Chains),
+			float64(ps.AdvancedRanks),
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestPawns(t *testing.T) {
+	for _, test := range []struct {
+		fen          string
+		white, black PawnStructure
+	}{
+		{
+			fen: "4k3/p7/8/3P4/2P5/8/P1P5/4K3 w - - 0 1",
+			white: PawnStructure{
+				Doubled:         1,
+				Isolated:        1,
+				Passed:          3,
+				ProtectedPassed: 1,
+				ConnectedPassed: 2,
+				Islands:         2,
+				Chains:          1,
+				AdvancedRanks:   5,
+			},
+			black: PawnStructure{Isolated: 1, Islands: 1},
+		},
+		{
+			// The d-pawn can't advance past the c5 pawn's guard
+			fen:   "4k3/8/8/2p5/2P1P3/3P4/8/4K3 w - - 0 1",
+			white: PawnStructure{Backward: 1, Passed: 1, ProtectedPassed: 1, Islands: 1, Chains: 1, AdvancedRanks: 5},
+			black: PawnStructure{Isolated: 1, Islands: 1, AdvancedRanks: 2},
+		},
+	} {
+		game := positionGame(t, test.fen)
+		if got := Pawns(game, chess.WhiteTeam); got != test.white {
+			t.Errorf("%s: got White %+v, want %+v", test.fen, got, test.white)
+		}
+		if got := Pawns(game, chess.BlackTeam); got != test.black {
+			t.Errorf("%s: got Black %+v, want %+v", test.fen, got, test.black)
+		}
+	}
+}
+
+// positionGame makes a game with no moves that starts from the
+// position described by fen.
+func positionGame(t *testing.T, fen string) chess.Game {
+	t.Helper()
+	game := chess.Game{Tags: map[string]string{"SetUp": "1", "FEN": fen}}
+	if err := game.Reset(); err != nil {
+		t.Fatalf("%s: %v", fen, err)
+	}
+	return game
+}
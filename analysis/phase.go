@@ -0,0 +1,83 @@
+package analysis
+
+import "github.com/mholt/chessml/chess"
+
+// GamePhase is the stage a game is in.
+type GamePhase int
+
+// The phases of a game.
+const (
+	Opening GamePhase = iota
+	Middlegame
+	Endgame
+)
+
+// String returns the name of the phase, like "middlegame".
+func (p GamePhase) String() string {
+	switch p {
+	case Opening:
+		return "opening"
+	case Middlegame:
+		return "middlegame"
+	case Endgame:
+		return "endgame"
+	default:
+		return "unknown"
+	}
+}
+
+// Phase returns the phase the game is in. It is the endgame
+// once the pieces other than pawns and kings are down to a
+// quarter of their value at the start (say, a rook and a minor
+// piece each), and the opening while more than half of the
+// knights and bishops are still on their starting squares.
+// Otherwise it is the middlegame.
+func Phase(game chess.Game) GamePhase {
+	b := game.Board
+	switch {
+	case materialPhase(b) <= MaxPhase/4:
+		return Endgame
+	case undevelopedMinors(b, chess.WhiteTeam)+undevelopedMinors(b, chess.BlackTeam) > 4:
+		return Opening
+	default:
+		return Middlegame
+	}
+}
+
+// PhaseValue returns how far the game is from the endgame, from
+// MaxPhase at the start down to 0 with only pawns and kings left.
+// Three quarters of it is by the material left, as the phase
+// used to taper piece-square tables, and a quarter by how many
+// knights and bishops are still on their starting squares, so
+// that it falls through the opening as well as after trades.
+func PhaseValue(game chess.Game) int {
+	b := game.Board
+	undeveloped := undevelopedMinors(b, chess.WhiteTeam) + undevelopedMinors(b, chess.BlackTeam)
+	development := undeveloped * MaxPhase / 8
+	return (3*materialPhase(b) + development) / 4
+}
+
+// PhaseFeature makes a feature of PhaseValue.
+func PhaseFeature() Feature {
+	return PerPosition("phase", Integer, func(game chess.Game) float64 {
+		return float64(PhaseValue(game))
+	})
+}
+
+// undevelopedMinors returns how many of player's knights and
+// bishops are on their starting squares.
+func undevelopedMinors(b chess.Board, player chess.Color) int {
+	row := 0
+	if player == chess.BlackTeam {
+		row = chess.Size - 1
+	}
+
+	n := 0
+	for c, rank := range [chess.Size]chess.Rank{chess.Empty, chess.Knight, chess.Bishop, chess.Empty, chess.Empty, chess.Bishop, chess.Knight, chess.Empty} {
+		p := b.Spaces[row][c]
+		if rank != chess.Empty && p.Rank == rank && p.Color == player {
+			n++
+		}
+	}
+	return n
+}
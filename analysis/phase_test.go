@@ -0,0 +1,29 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestPhase(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		game  chess.Game
+		phase GamePhase
+		value int
+	}{
+		{"start", positionGame(t, chess.StartFEN), Opening, MaxPhase},
+		{"developed", movesGame(t, "e4 e5 Nf3 Nc6 Bc4 Bc5 Nc3 Nf6"), Middlegame, 208},
+		{"queens", positionGame(t, "3qk3/8/8/8/8/8/8/3QK3 w - - 0 1"), Middlegame, 63},
+		{"rooks", positionGame(t, "r3k3/8/8/8/8/8/8/4K2R w - - 0 1"), Endgame, 31},
+		{"kings", positionGame(t, "4k3/8/8/8/8/8/8/4K3 w - - 0 1"), Endgame, 0},
+	} {
+		if got := Phase(test.game); got != test.phase {
+			t.Errorf("%s: got phase %v, want %v", test.name, got, test.phase)
+		}
+		if got := PhaseValue(test.game); got != test.value {
+			t.Errorf("%s: got phase value %d, want %d", test.name, got, test.value)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package analysis
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// A PieceSquareTable gives a value to a kind of piece on each
+// square, in centipawns, indexed like Board.Spaces from White's
+// side of the board. Black's values are the same with the rows
+// reversed.
+type PieceSquareTable [chess.Size][chess.Size]float64
+
+// PieceSquareTables holds a table for each kind of piece in the
+// middlegame and another in the endgame. Positions in between
+// get values between the two, by how much material is left.
+type PieceSquareTables struct {
+	Middlegame map[chess.Rank]PieceSquareTable
+	Endgame    map[chess.Rank]PieceSquareTable
+}
+
+// A PositionalScore is how well one color's pieces are placed
+// according to piece-square tables.
+type PositionalScore struct {
+	Score  float64      // sum of the pieces' scores, in pawns
+	Pieces []PieceScore // the score of each piece
+}
+
+// A PieceScore is the value of one piece on its square.
+type PieceScore struct {
+	Square     chess.Coord
+	Piece      chess.Piece
+	Middlegame float64 // value from the middlegame table, in pawns
+	Endgame    float64 // value from the endgame table, in pawns
+	Score      float64 // the two tapered by the phase of the game
+}
+
+// Evaluate scores the placement of player's pieces. It does not
+// include the value of the pieces themselves; see Material.
+func (t *PieceSquareTables) Evaluate(game chess.Game, player chess.Color) PositionalScore {
+	phase := materialPhase(game.Board)
+
+	var ps PositionalScore
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			p := game.Board.Spaces[r][c]
+			if p.Rank == chess.Empty || p.Color != player {
+				continue
+			}
+
+			row := r
+			if player == chess.BlackTeam {
+				row = chess.Size - 1 - r
+			}
+			mg := t.Middlegame[p.Rank][row][c] / 100
+			eg := t.Endgame[p.Rank][row][c] / 100
+			score := (mg*float64(phase) + eg*float64(MaxPhase-phase)) / MaxPhase
+
+			ps.Score += score
+			ps.Pieces = append(ps.Pieces, PieceScore{
+				Square:     chess.Coord{Row: r, Col: c},
+				Piece:      p,
+				Middlegame: mg,
+				Endgame:    eg,
+				Score:      score,
+			})
+		}
+	}
+
+	return ps
+}
+
+// PieceSquare computes the positional score of player's pieces
+// using the default piece-square tables.
+func PieceSquare(game chess.Game, player chess.Color) float64 {
+	return builtinTables().Evaluate(game, player).Score
+}
+
+// PieceSquareFeatures makes the piece-square feature, per color,
+// scoring positions with t, like tables from LoadTables. If t is
+// nil, the default tables are used.
+func PieceSquareFeatures(t *PieceSquareTables) []Feature {
+	if t == nil {
+		return PerColor("piece-square", Real, PieceSquare)
+	}
+	return PerColor("piece-square", Real, func(game chess.Game, player chess.Color) float64 {
+		return t.Evaluate(game, player).Score
+	})
+}
+
+// DefaultTables returns a copy of the built-in piece-square
+// tables, which are in pst.txt.
+func DefaultTables() *PieceSquareTables {
+	return builtinTables().copy()
+}
+
+// builtinTables returns the built-in tables, parsing them the
+// first time. They are shared, so they must not be modified.
+func builtinTables() *PieceSquareTables {
+	defaultOnce.Do(func() {
+		var err error
+		defaultTables, err = parseTables(strings.NewReader(defaultPST), nil)
+		if err != nil {
+			panic("analysis: bad built-in piece-square tables: " + err.Error())
+		}
+	})
+	return defaultTables
+}
+
+// LoadTables reads piece-square tables from the file at path;
+// see ReadTables.
+func LoadTables(path string) (*PieceSquareTables, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadTables(f)
+}
+
+// ReadTables reads piece-square tables in the format of pst.txt:
+// a line like "[middlegame knight]" followed by eight rows of
+// eight values, from the 8th rank to the 1st. Blank lines and
+// anything after a # are ignored. Tables that are left out are
+// the same as in the default tables.
+func ReadTables(r io.Reader) (*PieceSquareTables, error) {
+	return parseTables(r, builtinTables())
+}
+
+// parseTables reads tables from r into a copy of base, or into
+// empty tables if base is nil.
+func parseTables(r io.Reader, base *PieceSquareTables) (*PieceSquareTables, error) {
+	t := &PieceSquareTables{
+		Middlegame: make(map[chess.Rank]PieceSquareTable),
+		Endgame:    make(map[chess.Rank]PieceSquareTable),
+	}
+	if base != nil {
+		t = base.copy()
+	}
+
+	scanner := bufio.NewScanner(r)
+	var lineNum int
+	var tables map[chess.Rank]PieceSquareTable
+	var rank chess.Rank
+	var table PieceSquareTable
+	row := chess.Size // rows of the current table left to read
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if row > 0 && row < chess.Size {
+				return nil, fmt.Errorf("Line %d: table has only %d rows", lineNum, chess.Size-row)
+			}
+			fields := strings.Fields(strings.Trim(line, "[]"))
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("Line %d: expected a header like [middlegame pawn]", lineNum)
+			}
+			switch fields[0] {
+			case "middlegame":
+				tables = t.Middlegame
+			case "endgame":
+				tables = t.Endgame
+			default:
+				return nil, fmt.Errorf("Line %d: bad phase '%s'; must be middlegame or endgame", lineNum, fields[0])
+			}
+			var ok bool
+			rank, ok = pieceNames[fields[1]]
+			if !ok {
+				return nil, fmt.Errorf("Line %d: bad piece '%s'", lineNum, fields[1])
+			}
+			row = chess.Size
+			continue
+		}
+
+		if tables == nil || row == 0 {
+			return nil, fmt.Errorf("Line %d: values outside of a table", lineNum)
+		}
+		fields := strings.Fields(line)
+		if len(fields) != chess.Size {
+			return nil, fmt.Errorf("Line %d: expected %d values, got %d", lineNum, chess.Size, len(fields))
+		}
+		row--
+		for c, field := range fields {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("Line %d: bad value '%s'", lineNum, field)
+			}
+			table[row][c] = v
+		}
+		if row == 0 {
+			tables[rank] = table
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if row > 0 && row < chess.Size {
+		return nil, fmt.Errorf("Line %d: table has only %d rows", lineNum, chess.Size-row)
+	}
+
+	return t, nil
+}
+
+// copy returns a copy of the tables.
+func (t *PieceSquareTables) copy() *PieceSquareTables {
+	c := &PieceSquareTables{
+		Middlegame: make(map[chess.Rank]PieceSquareTable, len(t.Middlegame)),
+		Endgame:    make(map[chess.Rank]PieceSquareTable, len(t.Endgame)),
+	}
+	for rank, table := range t.Middlegame {
+		c.Middlegame[rank] = table
+	}
+	for rank, table := range t.Endgame {
+		c.Endgame[rank] = table
+	}
+	return c
+}
+
+// materialPhase returns how much of the pieces other than pawns
+// and kings are left on the board, from MaxPhase when all are
+// there down to 0 when none are. Knights and bishops count 1,
+// rooks 2 and queens 4, out of 24.
+func materialPhase(b chess.Board) int {
+	total := 0
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			switch b.Spaces[r][c].Rank {
+			case chess.Knight, chess.Bishop:
+				total++
+			case chess.Rook:
+				total += 2
+			case chess.Queen:
+				total += 4
+			}
+		}
+	}
+	if total > 24 {
+		total = 24 // more than the initial material, after promotions
+	}
+	return total * MaxPhase / 24
+}
+
+// MaxPhase is the phase value of a game with all its pieces.
+const MaxPhase = 256
+
+// pieceNames are the names of the pieces in table headers.
+var pieceNames = map[string]chess.Rank{
+	"pawn":   chess.Pawn,
+	"knight": chess.Knight,
+	"bishop": chess.Bishop,
+	"rook":   chess.Rook,
+	"queen":  chess.Queen,
+	"king":   chess.King,
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultTables *PieceSquareTables
+)
+
+// defaultPST is the text of the default tables.
+//
+//go:embed pst.txt
+var defaultPST string
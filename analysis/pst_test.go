@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// knightTables puts a knight on d4 (or d5 for Black) at 100
+// centipawns in the middlegame and 300 in the endgame.
+const knightTables = `
+[middlegame knight]
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 100 0 0 0 0   # d4
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+
+[endgame knight]
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 300 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+`
+
+func TestPieceSquareTables(t *testing.T) {
+	tables, err := ReadTables(strings.NewReader(knightTables))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One knight leaves 10/256 of the middlegame
+	want := (1.0*10 + 3.0*(MaxPhase-10)) / MaxPhase
+	for _, test := range []struct {
+		fen    string
+		player chess.Color
+		column int // of the player's feature
+	}{
+		{"4k3/8/8/8/3N4/8/8/4K3 w - - 0 1", chess.WhiteTeam, 0},
+		{"4k3/8/8/3n4/8/8/8/4K3 w - - 0 1", chess.BlackTeam, 1},
+	} {
+		game := positionGame(t, test.fen)
+		var knight float64
+		for _, ps := range tables.Evaluate(game, test.player).Pieces {
+			if ps.Piece.Rank == chess.Knight {
+				knight = ps.Score
+			}
+		}
+		if knight != want {
+			t.Errorf("%s: got knight score %g, want %g", test.fen, knight, want)
+		}
+
+		features := PieceSquareFeatures(tables)
+		got := ComputeFeatures(game, features)
+		for i, player := range []chess.Color{chess.WhiteTeam, chess.BlackTeam} {
+			if want := tables.Evaluate(game, player).Score; got[i] != want {
+				t.Errorf("%s: got %s %g, want %g", test.fen, features[i].Name(), got[i], want)
+			}
+		}
+		if defaults := ComputeFeatures(game, PieceSquareFeatures(nil)); got[test.column] == defaults[test.column] {
+			t.Errorf("%s: got %s %g, the same as with the default tables", test.fen, features[test.column].Name(), got[test.column])
+		}
+	}
+}
+
+func TestReadTablesErrors(t *testing.T) {
+	for _, input := range []string{
+		"[opening knight]\n",
+		"[middlegame archbishop]\n",
+		"[middlegame knight]\n0 0 0 0 0 0 0 0\n",
+		"[middlegame knight]\n0 0 0 0 0 0 0\n",
+		"[middlegame knight]\n0 0 0 0 0 0 0 x\n",
+		"0 0 0 0 0 0 0 0\n",
+	} {
+		if _, err := ReadTables(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
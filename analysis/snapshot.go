@@ -0,0 +1,35 @@
+package analysis
+
+import "github.com/mholt/chessml/chess"
+
+// A Snapshot is the position of a game at one point along
+// with the features computed from it, which are what the
+// ARFF files are made of. It encodes to JSON like this:
+//
+//	{
+//	  "position": {"fen": "...", "toMove": "white"},
+//	  "ply": 30,
+//	  "features": {"material": 1.04, "attack-value": 0.5, ...}
+//	}
+type Snapshot struct {
+	Position chess.Position     `json:"position"`
+	Ply      int                `json:"ply"` // number of moves played to reach the position
+	Features map[string]float64 `json:"features"`
+}
+
+// TakeSnapshot computes the registered features of the game
+// as it currently stands, with the moves executed so far.
+func TakeSnapshot(game chess.Game) Snapshot {
+	features := Features()
+
+	snap := Snapshot{
+		Position: game.Position(),
+		Ply:      game.Ply(),
+		Features: make(map[string]float64, len(features)),
+	}
+	for i, v := range ComputeFeatures(game, features) {
+		snap.Features[features[i].Name()] = v
+	}
+
+	return snap
+}
@@ -0,0 +1,436 @@
+package analysis
+
+import "github.com/mholt/chessml/chess"
+
+// MotifKind is a kind of tactical motif.
+type MotifKind int
+
+// The kinds of tactical motifs.
+const (
+	Fork MotifKind = iota
+	Pin
+	Skewer
+	DiscoveredAttack
+	BackRankWeakness
+	MateInOne
+)
+
+// String returns the name of the motif, like "discovered attack".
+func (k MotifKind) String() string {
+	switch k {
+	case Fork:
+		return "fork"
+	case Pin:
+		return "pin"
+	case Skewer:
+		return "skewer"
+	case DiscoveredAttack:
+		return "discovered attack"
+	case BackRankWeakness:
+		return "back rank weakness"
+	case MateInOne:
+		return "mate in one"
+	default:
+		return "unknown"
+	}
+}
+
+// A Motif is one tactic available to the side to move. What its
+// squares are depends on its kind:
+//
+//	Kind              Piece                        Move                        Targets
+//	Fork              the forking piece            the move that forks         the pieces forked
+//	Pin               the pinning piece            nil                         the pinned piece, then the one behind it
+//	Skewer            the skewering piece          the move that skewers       the skewered piece, then the one behind it
+//	DiscoveredAttack  the piece whose line opens   a move of the piece in      the piece attacked
+//	                                               the way, out of the line
+//	BackRankWeakness  the enemy king               nil                         the enemy pieces hemming it in
+//	MateInOne         the mating piece             the mating move             the enemy king
+type Motif struct {
+	Kind    MotifKind
+	Piece   chess.Coord
+	Move    *chess.ValidMove
+	Targets []chess.Coord
+}
+
+// Motifs finds all the tactical motifs available to the side
+// to move.
+func Motifs(game chess.Game) []Motif {
+	var motifs []Motif
+	for _, detect := range []func(chess.Game) []Motif{Forks, Pins, Skewers, DiscoveredAttacks, BackRankWeaknesses, MatesInOne} {
+		motifs = append(motifs, detect(game)...)
+	}
+	return motifs
+}
+
+// Forks finds the moves after which the moved piece attacks two
+// or more enemy pieces, each of which is the king, worth more
+// than the forking piece, or undefended. Knight and pawn forks
+// are the most common. The forking piece must not be left where
+// it can be taken for free or by a piece worth less.
+func Forks(game chess.Game) []Motif {
+	b := game.Board
+	player := game.ToMove()
+	enemy := opponent(player)
+
+	var motifs []Motif
+	for _, m := range chess.LegalMoves(b, player) {
+		after := applyMove(b, m)
+		value := PointValue(after.Spaces[m.To.Row][m.To.Col])
+		enemyAttacks := attackerLists(after, enemy)
+		if !safeAt(after, m.To, enemyAttacks) {
+			continue
+		}
+
+		var targets []chess.Coord
+		for _, sq := range Attacks(after, m.To.Row, m.To.Col) {
+			t := after.Spaces[sq.Row][sq.Col]
+			if t.Rank == chess.Empty || t.Color != enemy {
+				continue
+			}
+			if t.Rank == chess.King || PointValue(t) > value || len(enemyAttacks[sq.Row][sq.Col]) == 0 {
+				targets = append(targets, sq)
+			}
+		}
+		if len(targets) >= 2 {
+			move := m
+			motifs = append(motifs, Motif{Kind: Fork, Piece: m.From, Move: &move, Targets: targets})
+		}
+	}
+
+	return motifs
+}
+
+// Pins finds the enemy pieces that can't move off the line of
+// one of the side to move's bishops, rooks or queens without
+// exposing the king, or a piece worth more, behind them.
+func Pins(game chess.Game) []Motif {
+	b := game.Board
+	player := game.ToMove()
+	enemy := opponent(player)
+
+	var motifs []Motif
+	forEachLine(b, player, func(from chess.Coord, line []chess.Coord) {
+		if len(line) < 2 {
+			return
+		}
+		front := b.Spaces[line[0].Row][line[0].Col]
+		behind := b.Spaces[line[1].Row][line[1].Col]
+		if front.Color != enemy || behind.Color != enemy || front.Rank == chess.King {
+			return
+		}
+		if behind.Rank == chess.King || PointValue(behind) > PointValue(front) {
+			motifs = append(motifs, Motif{Kind: Pin, Piece: from, Targets: []chess.Coord{line[0], line[1]}})
+		}
+	})
+	return motifs
+}
+
+// Skewers finds the moves of the side to move's bishops, rooks and
+// queens after which the moved piece attacks an enemy piece that
+// is the king or worth more than the enemy piece behind it, which
+// can be taken once the first one moves away. Like with Forks, the
+// skewering piece must not be left where it can be taken for free
+// or by a piece worth less.
+func Skewers(game chess.Game) []Motif {
+	b := game.Board
+	player := game.ToMove()
+	enemy := opponent(player)
+
+	var motifs []Motif
+	for _, m := range chess.LegalMoves(b, player) {
+		var steps [][2]int
+		switch b.Spaces[m.From.Row][m.From.Col].Rank {
+		case chess.Bishop:
+			steps = kingSteps[4:]
+		case chess.Rook:
+			steps = kingSteps[:4]
+		case chess.Queen:
+			steps = kingSteps[:]
+		default:
+			continue
+		}
+
+		after := applyMove(b, m)
+		if !safeAt(after, m.To, attackerLists(after, enemy)) {
+			continue
+		}
+
+		for _, d := range steps {
+			line := firstPieces(after, m.To, d)
+			if len(line) < 2 {
+				continue
+			}
+			front := after.Spaces[line[0].Row][line[0].Col]
+			behind := after.Spaces[line[1].Row][line[1].Col]
+			if front.Color != enemy || behind.Color != enemy || behind.Rank == chess.King {
+				continue
+			}
+			if front.Rank == chess.King || PointValue(front) > PointValue(behind) {
+				move := m
+				motifs = append(motifs, Motif{Kind: Skewer, Piece: m.From, Move: &move, Targets: line})
+			}
+		}
+	}
+	return motifs
+}
+
+// DiscoveredAttacks finds the pieces of the side to move that
+// stand between one of its bishops, rooks or queens and an enemy
+// piece which is the king, worth more than the piece behind, or
+// undefended, and that have a legal move out of the line.
+func DiscoveredAttacks(game chess.Game) []Motif {
+	b := game.Board
+	player := game.ToMove()
+	enemy := opponent(player)
+	defended := AttackMap(b, enemy)
+	legal := chess.LegalMoves(b, player)
+
+	var motifs []Motif
+	forEachLine(b, player, func(from chess.Coord, line []chess.Coord) {
+		if len(line) < 2 {
+			return
+		}
+		blocker, target := line[0], line[1]
+		t := b.Spaces[target.Row][target.Col]
+		if b.Spaces[blocker.Row][blocker.Col].Color != player || t.Color != enemy {
+			return
+		}
+		if t.Rank != chess.King && PointValue(t) <= PointValue(b.Spaces[from.Row][from.Col]) && defended[target.Row][target.Col] > 0 {
+			return
+		}
+
+		// The blocker has to get out of the way
+		dr, dc := sign(target.Row-from.Row), sign(target.Col-from.Col)
+		for _, m := range legal {
+			if m.From != blocker || onLine(from, dr, dc, m.To) {
+				continue
+			}
+			move := m
+			motifs = append(motifs, Motif{Kind: DiscoveredAttack, Piece: from, Move: &move, Targets: []chess.Coord{target}})
+			return
+		}
+	})
+	return motifs
+}
+
+// BackRankWeaknesses finds whether the enemy king is on its back
+// rank and can't step off it, because each square in front of it
+// is held by one of its own pieces or attacked, while the side to
+// move has a rook or queen with a legal move to the rank and
+// nothing between it and the king there, and the enemy has no
+// rook or queen on the rank to guard it.
+func BackRankWeaknesses(game chess.Game) []Motif {
+	b := game.Board
+	player := game.ToMove()
+	enemy := opponent(player)
+
+	king, ok := findKing(b, enemy)
+	if !ok || relativeRank(king.Row, enemy) != 0 {
+		return nil
+	}
+	for c := 0; c < chess.Size; c++ {
+		p := b.Spaces[king.Row][c]
+		if p.Color == enemy && (p.Rank == chess.Rook || p.Rank == chess.Queen) {
+			return nil
+		}
+	}
+
+	attacked := AttackMap(b, player)
+	row := king.Row + pawnDirection(enemy)
+	var blockers []chess.Coord
+	for c := king.Col - 1; c <= king.Col+1; c++ {
+		if c < 0 || c >= chess.Size {
+			continue
+		}
+		p := b.Spaces[row][c]
+		switch {
+		case p.Rank != chess.Empty && p.Color == enemy:
+			blockers = append(blockers, chess.Coord{Row: row, Col: c})
+		case attacked[row][c] > 0:
+		default:
+			return nil // the king has somewhere to go
+		}
+	}
+
+	// A rook or queen has to be able to reach the rank with
+	// nothing between it and the king
+	for _, m := range chess.LegalMoves(b, player) {
+		p := b.Spaces[m.From.Row][m.From.Col]
+		if m.To.Row != king.Row || (p.Rank != chess.Rook && p.Rank != chess.Queen) {
+			continue
+		}
+		if clearOnRank(b, m.To, king, m.From) {
+			return []Motif{{Kind: BackRankWeakness, Piece: king, Targets: blockers}}
+		}
+	}
+	return nil
+}
+
+// clearOnRank returns whether the squares between from and to,
+// which are on the same rank, are empty, counting the square of
+// the piece moving, moving, as empty.
+func clearOnRank(b chess.Board, from, to, moving chess.Coord) bool {
+	lo, hi := from.Col, to.Col
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	for c := lo + 1; c < hi; c++ {
+		sq := chess.Coord{Row: from.Row, Col: c}
+		if b.Spaces[sq.Row][sq.Col].Rank != chess.Empty && sq != moving {
+			return false
+		}
+	}
+	return true
+}
+
+// MatesInOne finds the moves that checkmate the enemy king. A pawn
+// reaching the last rank is promoted to a queen.
+func MatesInOne(game chess.Game) []Motif {
+	b := game.Board
+	player := game.ToMove()
+	enemy := opponent(player)
+
+	var motifs []Motif
+	for _, m := range chess.LegalMoves(b, player) {
+		after := applyMove(b, m)
+		if chess.NumCheckingKing(after, enemy, false) == 0 || len(chess.LegalMoves(after, enemy)) > 0 {
+			continue
+		}
+		king, _ := findKing(after, enemy)
+		move := m
+		motifs = append(motifs, Motif{Kind: MateInOne, Piece: m.From, Move: &move, Targets: []chess.Coord{king}})
+	}
+	return motifs
+}
+
+// forEachLine calls fn for each line from each of player's bishops,
+// rooks and queens, with the squares of the first two pieces on it.
+func forEachLine(b chess.Board, player chess.Color, fn func(from chess.Coord, line []chess.Coord)) {
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			p := b.Spaces[r][c]
+			if p.Color != player {
+				continue
+			}
+
+			var steps [][2]int
+			switch p.Rank {
+			case chess.Bishop:
+				steps = kingSteps[4:]
+			case chess.Rook:
+				steps = kingSteps[:4]
+			case chess.Queen:
+				steps = kingSteps[:]
+			default:
+				continue
+			}
+
+			from := chess.Coord{Row: r, Col: c}
+			for _, d := range steps {
+				fn(from, firstPieces(b, from, d))
+			}
+		}
+	}
+}
+
+// firstPieces returns the squares of the first two pieces on the
+// line from from in the direction d.
+func firstPieces(b chess.Board, from chess.Coord, d [2]int) []chess.Coord {
+	var line []chess.Coord
+	for r, c := from.Row+d[0], from.Col+d[1]; r >= 0 && r < chess.Size && c >= 0 && c < chess.Size && len(line) < 2; r, c = r+d[0], c+d[1] {
+		if b.Spaces[r][c].Rank != chess.Empty {
+			line = append(line, chess.Coord{Row: r, Col: c})
+		}
+	}
+	return line
+}
+
+// safeAt returns whether the piece on sq can't be taken for free
+// or by a piece worth less, given the enemy's attackers of each
+// square, from attackerLists.
+func safeAt(b chess.Board, sq chess.Coord, enemyAttacks [chess.Size][chess.Size][]chess.Coord) bool {
+	p := b.Spaces[sq.Row][sq.Col]
+	attackers := enemyAttacks[sq.Row][sq.Col]
+	if len(attackers) == 0 {
+		return true
+	}
+	if len(attackerLists(b, p.Color)[sq.Row][sq.Col]) == 0 {
+		return false
+	}
+	for _, a := range attackers {
+		if q := b.Spaces[a.Row][a.Col]; q.Rank != chess.King && PointValue(q) < PointValue(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// onLine returns whether sq is on the line from from in the
+// direction dr,dc.
+func onLine(from chess.Coord, dr, dc int, sq chess.Coord) bool {
+	for r, c := from.Row+dr, from.Col+dc; r >= 0 && r < chess.Size && c >= 0 && c < chess.Size; r, c = r+dr, c+dc {
+		if r == sq.Row && c == sq.Col {
+			return true
+		}
+	}
+	return false
+}
+
+// applyMove returns the board after making a legal move on it,
+// moving the rook too when castling, removing the captured pawn
+// for en passant, and promoting pawns to queens.
+func applyMove(b chess.Board, m chess.ValidMove) chess.Board {
+	after := b.Copy()
+	p := after.Spaces[m.From.Row][m.From.Col]
+
+	if p.Rank == chess.King && m.To.Col-m.From.Col == 2 {
+		after.MovePiece(chess.Coord{Row: m.From.Row, Col: chess.Size - 1}, chess.Coord{Row: m.From.Row, Col: m.To.Col - 1})
+	} else if p.Rank == chess.King && m.From.Col-m.To.Col == 2 {
+		after.MovePiece(chess.Coord{Row: m.From.Row, Col: 0}, chess.Coord{Row: m.From.Row, Col: m.To.Col + 1})
+	}
+
+	after.MovePiece(m.From, m.To)
+	if m.EnPassant {
+		after.Spaces[m.From.Row][m.To.Col].Rank = chess.Empty
+	}
+	if p.Rank == chess.Pawn && (m.To.Row == 0 || m.To.Row == chess.Size-1) {
+		after.Spaces[m.To.Row][m.To.Col].Rank = chess.Queen
+	}
+
+	return after
+}
+
+// sign returns -1, 0 or 1 by the sign of n.
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// MotifFeatures makes a feature counting each kind of motif, for
+// the side to move. They aren't registered by default, since most
+// of the detectors try every legal move; register them to include
+// them in datasets.
+func MotifFeatures() []Feature {
+	return []Feature{
+		PerPosition("forks", Integer, motifFeature(Forks)),
+		PerPosition("pins", Integer, motifFeature(Pins)),
+		PerPosition("skewers", Integer, motifFeature(Skewers)),
+		PerPosition("discovered-attacks", Integer, motifFeature(DiscoveredAttacks)),
+		PerPosition("back-rank-weakness", Boolean, motifFeature(BackRankWeaknesses)),
+		PerPosition("mates-in-one", Integer, motifFeature(MatesInOne)),
+	}
+}
+
+// motifFeature makes a feature function that counts the motifs
+// that detect finds.
+func motifFeature(detect func(chess.Game) []Motif) func(chess.Game) float64 {
+	return func(game chess.Game) float64 {
+		return float64(len(detect(game)))
+	}
+}
@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestMotifs(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		fen  string
+		want []string // see describeMotif
+	}{
+		{
+			name: "knight fork",
+			fen:  "r3k3/8/8/3N4/8/8/8/4K3 w - - 0 1",
+			want: []string{"fork d5 d5c7 e8 a8"},
+		},
+		{
+			name: "absolute pin",
+			fen:  "4k3/4n3/8/8/8/8/8/4RK2 w - - 0 1",
+			want: []string{"pin e1 e7 e8"},
+		},
+		{
+			name: "king skewer",
+			fen:  "q7/8/8/8/k7/8/8/1R2K3 w - - 0 1",
+			want: []string{"skewer b1 b1a1 a4 a8"},
+		},
+		{
+			name: "discovered check",
+			fen:  "4k3/8/8/8/8/8/4N3/4RK2 w - - 0 1",
+			want: []string{"discovered attack e1 e2d4 e8"},
+		},
+		{
+			name: "back-rank mate",
+			fen:  "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+			want: []string{"back rank weakness g8 f7 g7 h7", "mate in one a1 a1a8 g8"},
+		},
+		{
+			// The bishop guards the back rank
+			name: "blocked back rank",
+			fen:  "3b2k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1",
+		},
+		{
+			// Black's move, so White's rook doesn't count
+			name: "other side to move",
+			fen:  "6k1/5ppp/8/8/8/8/8/R5K1 b - - 0 1",
+		},
+	} {
+		var got []string
+		for _, m := range Motifs(positionGame(t, test.fen)) {
+			got = append(got, describeMotif(m))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got motifs %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMotifFeatures(t *testing.T) {
+	game := positionGame(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	features := MotifFeatures()
+	got := ComputeFeatures(game, features)
+	want := []float64{0, 0, 0, 0, 1, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got motif features %v, want %v", got, want)
+	}
+
+	for _, f := range features {
+		if LookupFeature(f.Name()) != nil {
+			t.Errorf("Feature %s is registered by default", f.Name())
+		}
+	}
+}
+
+// describeMotif describes m as its kind, the square of its piece,
+// its move in UCI if it has one, and the squares of its targets.
+func describeMotif(m Motif) string {
+	sq := func(c chess.Coord) string {
+		return strings.ToLower(chess.CoordToNotation(c))
+	}
+	s := fmt.Sprintf("%s %s", m.Kind, sq(m.Piece))
+	if m.Move != nil {
+		s += " " + sq(m.Move.From) + sq(m.Move.To)
+	}
+	for _, target := range m.Targets {
+		s += " " + sq(target)
+	}
+	return s
+}
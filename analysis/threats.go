@@ -0,0 +1,109 @@
+package analysis
+
+import "github.com/mholt/chessml/chess"
+
+// Threats counts the pieces of one color that are in immediate
+// danger, and the sum of their PointValues. Kings are never
+// counted as threatened; an attacked king is in check.
+type Threats struct {
+	Hanging      int     // pieces attacked by the enemy and not defended
+	HangingValue float64 // their total value
+
+	AttackedByLesser      int     // pieces attacked by an enemy piece of lower value, other than the king
+	AttackedByLesserValue float64 // their total value
+
+	Overloaded      int     // pieces that are the only defender of two or more attacked pieces
+	OverloadedValue float64 // their total value
+}
+
+// ThreatsTo finds the threats to player's pieces.
+func ThreatsTo(game chess.Game, player chess.Color) Threats {
+	b := game.Board
+	attackers := attackerLists(b, opponent(player))
+	defenders := attackerLists(b, player)
+
+	var t Threats
+	var soleDefenses [chess.Size][chess.Size]int // attacked pieces each piece alone defends
+
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			p := b.Spaces[r][c]
+			if p.Rank == chess.Empty || p.Rank == chess.King || p.Color != player {
+				continue
+			}
+			if len(attackers[r][c]) == 0 {
+				continue
+			}
+			value := PointValue(p)
+
+			switch len(defenders[r][c]) {
+			case 0:
+				t.Hanging++
+				t.HangingValue += value
+			case 1:
+				d := defenders[r][c][0]
+				soleDefenses[d.Row][d.Col]++
+			}
+
+			for _, sq := range attackers[r][c] {
+				a := b.Spaces[sq.Row][sq.Col]
+				if a.Rank != chess.King && PointValue(a) < value {
+					t.AttackedByLesser++
+					t.AttackedByLesserValue += value
+					break
+				}
+			}
+		}
+	}
+
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			if soleDefenses[r][c] >= 2 {
+				t.Overloaded++
+				t.OverloadedValue += PointValue(b.Spaces[r][c])
+			}
+		}
+	}
+
+	return t
+}
+
+// attackerLists returns the squares of player's pieces that
+// attack each square of the board, indexed like Board.Spaces.
+func attackerLists(b chess.Board, player chess.Color) [chess.Size][chess.Size][]chess.Coord {
+	var lists [chess.Size][chess.Size][]chess.Coord
+	for r := 0; r < chess.Size; r++ {
+		for c := 0; c < chess.Size; c++ {
+			if b.Spaces[r][c].Rank == chess.Empty || b.Spaces[r][c].Color != player {
+				continue
+			}
+			for _, sq := range Attacks(b, r, c) {
+				lists[sq.Row][sq.Col] = append(lists[sq.Row][sq.Col], chess.Coord{Row: r, Col: c})
+			}
+		}
+	}
+	return lists
+}
+
+// ThreatFeatures makes a feature for each value of Threats, per
+// color, computing ThreatsTo once for all of them.
+func ThreatFeatures() []Feature {
+	return PerColorGroup([]Column{
+		{"hanging-pieces", Integer},
+		{"hanging-value", Real},
+		{"attacked-by-lesser", Integer},
+		{"attacked-by-lesser-value", Real},
+		{"overloaded-pieces", Integer},
+		{"overloaded-value", Real},
+	}, func(game chess.Game, player chess.Color) []float64 {
+		t := ThreatsTo(game, player)
+		return []float64{
+			float64(t.Hanging),
+			t.HangingValue,
+			float64(t.AttackedByLesser),
+			t.AttackedByLesserValue,
+			float64(t.Overloaded),
+			t.OverloadedValue,
+		}
+	})
+}
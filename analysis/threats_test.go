@@ -0,0 +1,39 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestThreatsTo(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		fen          string
+		white, black Threats
+	}{
+		{
+			name:  "hanging knight",
+			fen:   "4k3/8/8/3n4/8/8/8/3QK3 w - - 0 1",
+			black: Threats{Hanging: 1, HangingValue: 3.2},
+		},
+		{
+			name:  "rook attacked by a pawn",
+			fen:   "4k3/8/8/2p5/3R4/8/8/3RK3 w - - 0 1",
+			white: Threats{AttackedByLesser: 1, AttackedByLesserValue: 5.1},
+		},
+		{
+			name:  "rook defending two knights",
+			fen:   "r2r3k/8/8/8/3N4/8/7K/N2R4 w - - 0 1",
+			white: Threats{Overloaded: 1, OverloadedValue: 5.1},
+		},
+	} {
+		game := positionGame(t, test.fen)
+		if got := ThreatsTo(game, chess.WhiteTeam); got != test.white {
+			t.Errorf("%s: got White %+v, want %+v", test.name, got, test.white)
+		}
+		if got := ThreatsTo(game, chess.BlackTeam); got != test.black {
+			t.Errorf("%s: got Black %+v, want %+v", test.name, got, test.black)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/mholt/chessml/analysis"
 	"github.com/mholt/chessml/chess"
@@ -15,7 +16,8 @@ import (
 // pctMoves of the way through the game. In other words, a pctMoves
 // with {0.3, 0.5} will play 30% of the moves and then snapshot the game,
 // writing a line into the ARFF file,  do that again for 50% of the
-// way through the game, etc.
+// way through the game, etc. There is a column for each feature
+// registered in the analysis package, then one for the outcome.
 func GenerateARFF(games []chess.Game, pctMoves []float64, filename string) {
 	sort.Float64s(pctMoves)
 
@@ -26,14 +28,8 @@ func GenerateARFF(games []chess.Game, pctMoves []float64, filename string) {
 
 	defer f.Close()
 
-	f.WriteString("%% Title: Database for predicting chess outcomes\n\n")
-	f.WriteString("@relation chess\n\n")
-	f.WriteString("@attribute material       REAL\n")
-	f.WriteString("@attribute attack-value   REAL\n")
-	f.WriteString("@attribute mobility       REAL\n")
-	f.WriteString("@attribute space          REAL\n")
-	f.WriteString("@attribute winloss        REAL\n\n")
-	f.WriteString("@data\n%%\n%% " + strconv.Itoa(len(games)*len(pctMoves)) + " instances\n%%\n")
+	features := analysis.Features()
+	writeHeader(f, features, nil, len(games)*len(pctMoves))
 
 	for _, game := range games {
 		for _, pct := range pctMoves {
@@ -46,33 +42,88 @@ func GenerateARFF(games []chess.Game, pctMoves []float64, filename string) {
 				continue
 			}
 
-			material := (analysis.Material(game, chess.WhiteTeam) + 1) / (analysis.Material(game, chess.BlackTeam) + 1)
-			attackValue := (analysis.AttackValue(game, chess.WhiteTeam) + 1) / (analysis.AttackValue(game, chess.BlackTeam) + 1)
-			mobility := (analysis.Mobility(game, chess.WhiteTeam) + 1) / (analysis.Mobility(game, chess.BlackTeam) + 1)
-			space := (analysis.Space(game, chess.WhiteTeam) + 1) / (analysis.Space(game, chess.BlackTeam) + 1)
+			values := featureValues(game, features)
 
-			// Finish executing game to know how extreme the win/loss is
-			err = game.Execute(-1)
+			winloss, err := outcome(&game)
 			if err != nil {
 				log.Println(err)
 				log.Println("^ Skipping that game")
 				continue
 			}
 
-			// For now, we assume that we are training to predict WHITE's move (ie. it's white's turn)
-			var outcome float64
-			switch game.Tags["Result"] {
-			case chess.WhiteWin:
-				outcome = float64(analysis.Material(game, chess.WhiteTeam)) / float64(analysis.Material(game, chess.BlackTeam))
-			case chess.BlackWin:
-				outcome = -float64(analysis.Material(game, chess.BlackTeam)) / float64(analysis.Material(game, chess.WhiteTeam))
-			default:
-				outcome = 0
+			values = append(values, formatValue(analysis.Real, winloss))
+			f.WriteString(strings.Join(values, ",") + "\n")
+
+			game.Reset()
+		}
+	}
+
+	f.WriteString("%%\n%%\n%%\n")
+
+	f.Sync()
+}
+
+// GenerateARFFByPhase makes an ARFF file like GenerateARFF, but
+// snapshots each game once in each of phases that it reaches,
+// halfway through the moves it spends in that phase. There is a
+// column for the phase, after those of the features.
+func GenerateARFFByPhase(games []chess.Game, phases []analysis.GamePhase, filename string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		panic(err)
+	}
+
+	defer f.Close()
+
+	// Find where each game is in each phase first, to know
+	// how many instances there will be
+	plies := make([]map[analysis.GamePhase]int, len(games))
+	instances := 0
+	for i, game := range games {
+		plies[i], err = phasePlies(game)
+		if err != nil {
+			log.Println(err)
+			log.Println("^ Skipping that game")
+			continue
+		}
+		for _, phase := range phases {
+			if _, ok := plies[i][phase]; ok {
+				instances++
 			}
+		}
+	}
 
-			f.WriteString(fmt.Sprintf("%f,%f,%f,%f,%f\n", material, attackValue, mobility, space, outcome))
+	features := analysis.Features()
+	phaseAttribute := fmt.Sprintf("%-14s {%s,%s,%s}", "game-phase", analysis.Opening, analysis.Middlegame, analysis.Endgame)
+	writeHeader(f, features, []string{phaseAttribute}, instances)
+
+	for i, game := range games {
+		for _, phase := range phases {
+			ply, ok := plies[i][phase]
+			if !ok {
+				continue
+			}
 
 			game.Reset()
+			err := game.Execute(ply)
+			if err != nil {
+				log.Println(err)
+				log.Println("^ Skipping that game")
+				continue
+			}
+
+			values := featureValues(game, features)
+			values = append(values, phase.String())
+
+			winloss, err := outcome(&game)
+			if err != nil {
+				log.Println(err)
+				log.Println("^ Skipping that game")
+				continue
+			}
+
+			values = append(values, formatValue(analysis.Real, winloss))
+			f.WriteString(strings.Join(values, ",") + "\n")
 		}
 	}
 
@@ -80,3 +131,101 @@ func GenerateARFF(games []chess.Game, pctMoves []float64, filename string) {
 
 	f.Sync()
 }
+
+// writeHeader writes the ARFF header, with an attribute for each
+// of features, then for each of extra, then for the outcome.
+func writeHeader(f *os.File, features []analysis.Feature, extra []string, instances int) {
+	f.WriteString("%% Title: Database for predicting chess outcomes\n\n")
+	f.WriteString("@relation chess\n\n")
+	for _, feature := range features {
+		f.WriteString(fmt.Sprintf("@attribute %-14s %s\n", feature.Name(), attributeType(feature.Type())))
+	}
+	for _, attribute := range extra {
+		f.WriteString("@attribute " + attribute + "\n")
+	}
+	f.WriteString("@attribute winloss        REAL\n\n")
+	f.WriteString("@data\n%%\n%% " + strconv.Itoa(instances) + " instances\n%%\n")
+}
+
+// featureValues computes the features of the game as it
+// stands, formatted for the data section.
+func featureValues(game chess.Game, features []analysis.Feature) []string {
+	var values []string
+	for i, v := range analysis.ComputeFeatures(game, features) {
+		values = append(values, formatValue(features[i].Type(), v))
+	}
+	return values
+}
+
+// outcome finishes executing the game to know how extreme the
+// win or loss is.
+func outcome(game *chess.Game) (float64, error) {
+	err := game.Execute(-1)
+	if err != nil {
+		return 0, err
+	}
+
+	// For now, we assume that we are training to predict WHITE's move (ie. it's white's turn)
+	switch game.Tags["Result"] {
+	case chess.WhiteWin:
+		return float64(analysis.Material(*game, chess.WhiteTeam)) / float64(analysis.Material(*game, chess.BlackTeam)), nil
+	case chess.BlackWin:
+		return -float64(analysis.Material(*game, chess.BlackTeam)) / float64(analysis.Material(*game, chess.WhiteTeam)), nil
+	default:
+		return 0, nil
+	}
+}
+
+// phasePlies replays the game and returns, for each phase it
+// reaches, the ply halfway between the first and last positions
+// in that phase.
+func phasePlies(game chess.Game) (map[analysis.GamePhase]int, error) {
+	first := make(map[analysis.GamePhase]int)
+	last := make(map[analysis.GamePhase]int)
+
+	err := game.Reset()
+	if err != nil {
+		return nil, err
+	}
+	for ply := 0; ; ply++ {
+		phase := analysis.Phase(game)
+		if _, ok := first[phase]; !ok {
+			first[phase] = ply
+		}
+		last[phase] = ply
+
+		if ply == len(game.Moves) {
+			break
+		}
+		err := game.Execute(1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	plies := make(map[analysis.GamePhase]int)
+	for phase, ply := range first {
+		plies[phase] = (ply + last[phase]) / 2
+	}
+	return plies, nil
+}
+
+// attributeType returns the ARFF type of a feature type.
+func attributeType(t analysis.FeatureType) string {
+	switch t {
+	case analysis.Integer:
+		return "INTEGER"
+	case analysis.Boolean:
+		return "{0,1}"
+	default:
+		return "REAL"
+	}
+}
+
+// formatValue formats a feature's value for the data section.
+func formatValue(t analysis.FeatureType, v float64) string {
+	if t == analysis.Real {
+		return strconv.FormatFloat(v, 'f', 6, 64)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mholt/chessml/chess"
+	"github.com/mholt/chessml/pgn"
+	"github.com/mholt/chessml/polyglot"
+)
+
+// buildBook makes a Polyglot opening book from the games in
+// the PGN files named in args, after any flags.
+func buildBook(args []string) {
+	fs := flag.NewFlagSet("book", flag.ExitOnError)
+	out := fs.String("o", "book.bin", "write the book to this `file`")
+	maxPly := fs.Int("maxply", 30, "add this many `plies` of each game")
+	minGames := fs.Int("mingames", 1, "keep only moves played in at least this many `games`")
+	color := fs.String("color", "", "make a book for only this `color` (white or black)")
+	minElo := fs.Int("minelo", 0, "leave out the moves of players rated below this")
+	eloWeighting := fs.Bool("eloweight", false, "weigh moves by the rating of the player making them")
+	win := fs.Float64("win", 2, "weight of a move from a won game")
+	draw := fs.Float64("draw", 1, "weight of a move from a drawn game")
+	loss := fs.Float64("loss", 0, "weight of a move from a lost game")
+	filterExpr := fs.String("filter", "", "only use games whose tags match this `expression`")
+	fs.Parse(args)
+
+	opts := polyglot.BuildOptions{
+		MaxPly:       *maxPly,
+		MinGames:     *minGames,
+		MinElo:       *minElo,
+		EloWeighting: *eloWeighting,
+		WinWeight:    *win,
+		DrawWeight:   *draw,
+		LossWeight:   *loss,
+	}
+	switch *color {
+	case "":
+	case "white":
+		opts.Color = chess.WhiteTeam
+	case "black":
+		opts.Color = chess.BlackTeam
+	default:
+		log.Fatalf("Bad color '%s'; must be white or black", *color)
+	}
+
+	var filter *pgn.Filter
+	if *filterExpr != "" {
+		var err error
+		filter, err = pgn.ParseFilter(*filterExpr)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	builder, err := polyglot.NewBuilder(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	count := 0
+
+	for _, path := range fs.Args() {
+		f, err := pgn.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		popts := pgn.ParallelOptions{Lenient: true, Filter: filter}
+		gameErrs, err := pgn.ParseParallel(f, popts, func(game chess.Game) error {
+			count++
+			if err := builder.Add(game); err != nil {
+				log.Printf("%s: %v; skipping the rest of that game", path, err)
+			}
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, gameErr := range gameErrs {
+			log.Printf("%s: %v; skipping that game", path, gameErr)
+		}
+	}
+
+	entries := builder.Entries()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = polyglot.WriteBook(f, entries)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Wrote %d entries from %d games to %s\n", len(entries), count, *out)
+}
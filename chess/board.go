@@ -10,7 +10,8 @@ import (
 
 // A Board represents a chess board.
 type Board struct {
-	Spaces [Size][Size]Piece
+	Spaces   [Size][Size]Piece
+	Castling CastleRights
 }
 
 // Setup resets the board state, placing pieces in their initial positions.
@@ -59,6 +60,8 @@ func (b *Board) Setup() {
 	placePiece(0, 7, Rook, WhiteTeam)
 	placePiece(7, 0, Rook, BlackTeam)
 	placePiece(7, 7, Rook, BlackTeam)
+
+	b.Castling = AllCastleRights
 }
 
 // String creates a string representation of the current state of the board.
@@ -108,6 +111,17 @@ func (b *Board) MovePiece(from, to Coord) (replaced Piece, err error) {
 		return replaced, fmt.Errorf("No piece to move at row,col (%d,%d)", from.Row, from.Col)
 	}
 
+	// A king or rook leaving its home square, or a rook
+	// being captured on it, loses the right to castle
+	if b.Spaces[from.Row][from.Col].Rank == King {
+		if b.Spaces[from.Row][from.Col].Color == WhiteTeam {
+			b.Castling &^= WhiteKingside | WhiteQueenside
+		} else {
+			b.Castling &^= BlackKingside | BlackQueenside
+		}
+	}
+	b.Castling &^= cornerCastleRights[from] | cornerCastleRights[to]
+
 	replaced = b.Spaces[to.Row][to.Col]
 	b.Spaces[to.Row][to.Col] = b.Spaces[from.Row][from.Col]
 	b.Spaces[from.Row][from.Col].Rank = Empty
@@ -133,7 +147,7 @@ func (b *Board) MovePiece(from, to Coord) (replaced Piece, err error) {
 
 // copy makes a deep copy of the board
 func (b *Board) Copy() Board {
-	b2 := Board{}
+	b2 := Board{Castling: b.Castling}
 	for i := 0; i < Size; i++ {
 		for j := 0; j < Size; j++ {
 			b2.Spaces[i][j] = b.Spaces[i][j]
@@ -308,4 +322,12 @@ var (
 
 	// Converts file strings (a-f) to col index (0-7) - case-sensitive!
 	fileToCol = map[string]int{"a": 0, "b": 1, "c": 2, "d": 3, "e": 4, "f": 5, "g": 6, "h": 7}
+
+	// The castling right lost when a rook's home square is vacated or captured on
+	cornerCastleRights = map[Coord]CastleRights{
+		{Row: 0, Col: 7}: WhiteKingside,
+		{Row: 0, Col: 0}: WhiteQueenside,
+		{Row: 7, Col: 7}: BlackKingside,
+		{Row: 7, Col: 0}: BlackQueenside,
+	}
 )
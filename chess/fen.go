@@ -0,0 +1,230 @@
+package chess
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// A Position is the state of a game at one point in time:
+// the board along with whose turn it is and the move counters.
+// It is what a FEN record describes.
+type Position struct {
+	Board      Board
+	ToMove     Color
+	HalfMoves  int // plies since the last capture or pawn move
+	MoveNumber int // number of the current turn, starting at 1
+}
+
+// ParseFEN parses a position in Forsyth-Edwards Notation.
+// The halfmove clock and fullmove number may be omitted, as
+// they are in EPD records, in which case they are 0 and 1.
+func ParseFEN(fen string) (Position, error) {
+	var pos Position
+
+	fields := strings.Fields(fen)
+	if len(fields) != 4 && len(fields) != 6 {
+		return pos, errors.New("FEN must have 4 or 6 fields; got: '" + fen + "'")
+	}
+
+	// Piece placement, from rank 8 down to rank 1
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != Size {
+		return pos, errors.New("FEN must have 8 ranks; got: '" + fields[0] + "'")
+	}
+	for i, rank := range ranks {
+		row, col := Size-1-i, 0
+		for _, ch := range rank {
+			if ch >= '1' && ch <= '8' {
+				col += int(ch - '0')
+				continue
+			}
+			piece, ok := fenToPiece[ch]
+			if !ok || col >= Size {
+				return pos, errors.New("Bad rank in FEN: '" + rank + "'")
+			}
+			pos.Board.Spaces[row][col] = piece
+			col++
+		}
+		if col != Size {
+			return pos, errors.New("Bad rank in FEN: '" + rank + "'")
+		}
+	}
+
+	// Active color
+	switch fields[1] {
+	case "w":
+		pos.ToMove = WhiteTeam
+	case "b":
+		pos.ToMove = BlackTeam
+	default:
+		return pos, errors.New("Bad active color in FEN: '" + fields[1] + "'")
+	}
+
+	// Castling availability
+	if fields[2] != "-" {
+		for _, ch := range fields[2] {
+			right, ok := fenToCastle[ch]
+			if !ok {
+				return pos, errors.New("Bad castling availability in FEN: '" + fields[2] + "'")
+			}
+			pos.Board.Castling |= right
+		}
+	}
+
+	// En passant target square; the pawn that just moved
+	// past it is the one that may be captured
+	if fields[3] != "-" {
+		if len(fields[3]) != 2 || !isFile[fields[3][0]] || (fields[3][1] != '3' && fields[3][1] != '6') {
+			return pos, errors.New("Bad en passant square in FEN: '" + fields[3] + "'")
+		}
+		target := NotationToCoord(fields[3])
+		row := target.Row + 1
+		if target.Row == 5 {
+			row = target.Row - 1
+		}
+		if pos.Board.Spaces[row][target.Col].Rank == Pawn {
+			pos.Board.Spaces[row][target.Col].EnPassantable = true
+		}
+	}
+
+	pos.MoveNumber = 1
+	if len(fields) == 6 {
+		var err error
+		pos.HalfMoves, err = strconv.Atoi(fields[4])
+		if err != nil || pos.HalfMoves < 0 {
+			return pos, errors.New("Bad halfmove clock in FEN: '" + fields[4] + "'")
+		}
+		pos.MoveNumber, err = strconv.Atoi(fields[5])
+		if err != nil || pos.MoveNumber < 1 {
+			return pos, errors.New("Bad fullmove number in FEN: '" + fields[5] + "'")
+		}
+	}
+
+	return pos, nil
+}
+
+// FEN returns the position in Forsyth-Edwards Notation.
+func (p Position) FEN() string {
+	var sb strings.Builder
+
+	for row := Size - 1; row >= 0; row-- {
+		empty := 0
+		for col := 0; col < Size; col++ {
+			piece := p.Board.Spaces[row][col]
+			if piece.Rank == Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			sb.WriteRune(pieceToFEN(piece))
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		if row > 0 {
+			sb.WriteByte('/')
+		}
+	}
+
+	if p.ToMove == BlackTeam {
+		sb.WriteString(" b ")
+	} else {
+		sb.WriteString(" w ")
+	}
+
+	if p.Board.Castling == 0 {
+		sb.WriteByte('-')
+	}
+	for _, ch := range "KQkq" {
+		if p.Board.Castling&fenToCastle[ch] != 0 {
+			sb.WriteRune(ch)
+		}
+	}
+
+	sb.WriteByte(' ')
+	if target, ok := p.EnPassantTarget(); ok {
+		sb.WriteString(strings.ToLower(CoordToNotation(target)))
+	} else {
+		sb.WriteByte('-')
+	}
+
+	sb.WriteString(" " + strconv.Itoa(p.HalfMoves) + " " + strconv.Itoa(p.MoveNumber))
+
+	return sb.String()
+}
+
+// EnPassantTarget returns the square passed over by a pawn
+// that just moved two squares, if there is one.
+func (p Position) EnPassantTarget() (Coord, bool) {
+	// Only the pawns of the player who just moved count;
+	// the flags of the other player's are stale
+	row, dir := 3, -1
+	if p.ToMove == WhiteTeam {
+		row, dir = 4, 1
+	}
+	for col := 0; col < Size; col++ {
+		piece := p.Board.Spaces[row][col]
+		if piece.Rank == Pawn && piece.Color != p.ToMove && piece.EnPassantable {
+			return Coord{Row: row + dir, Col: col}, true
+		}
+	}
+	return Coord{}, false
+}
+
+// pieceToFEN returns the FEN letter for p: upper
+// case for White and lower case for Black.
+func pieceToFEN(p Piece) rune {
+	for ch, piece := range fenToPiece {
+		if piece.Rank == p.Rank && piece.Color == p.Color {
+			return ch
+		}
+	}
+	return '?'
+}
+
+// CastleRights is a set of the castles that are still
+// allowed, as far as the kings and rooks having moved.
+type CastleRights uint8
+
+// Castling rights
+const (
+	WhiteKingside CastleRights = 1 << iota
+	WhiteQueenside
+	BlackKingside
+	BlackQueenside
+
+	AllCastleRights = WhiteKingside | WhiteQueenside | BlackKingside | BlackQueenside
+)
+
+// StartFEN is the standard initial position.
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+var (
+	// Map of FEN letters to pieces
+	fenToPiece = map[rune]Piece{
+		'K': {Color: WhiteTeam, Rank: King},
+		'Q': {Color: WhiteTeam, Rank: Queen},
+		'B': {Color: WhiteTeam, Rank: Bishop},
+		'N': {Color: WhiteTeam, Rank: Knight},
+		'R': {Color: WhiteTeam, Rank: Rook},
+		'P': {Color: WhiteTeam, Rank: Pawn},
+		'k': {Color: BlackTeam, Rank: King},
+		'q': {Color: BlackTeam, Rank: Queen},
+		'b': {Color: BlackTeam, Rank: Bishop},
+		'n': {Color: BlackTeam, Rank: Knight},
+		'r': {Color: BlackTeam, Rank: Rook},
+		'p': {Color: BlackTeam, Rank: Pawn},
+	}
+
+	// Map of FEN castling letters to castling rights
+	fenToCastle = map[rune]CastleRights{
+		'K': WhiteKingside,
+		'Q': WhiteQueenside,
+		'k': BlackKingside,
+		'q': BlackQueenside,
+	}
+)
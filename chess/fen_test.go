@@ -0,0 +1,76 @@
+package chess
+
+import "testing"
+
+func TestFENRoundTrip(t *testing.T) {
+	for _, fen := range []string{
+		StartFEN,
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3",
+		"r3k2r/8/8/8/8/8/8/R3K2R w Kq - 12 40",
+		"4k3/8/8/8/8/8/8/4K3 b - - 99 120",
+	} {
+		pos, err := ParseFEN(fen)
+		if err != nil {
+			t.Errorf("%s: %v", fen, err)
+			continue
+		}
+		if got := pos.FEN(); got != fen {
+			t.Errorf("Got FEN %s, want %s", got, fen)
+		}
+	}
+}
+
+func TestParseFEN(t *testing.T) {
+	pos, err := ParseFEN("r3k2r/8/8/3pP3/8/8/8/R3K2R w Kq d6 7 31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.ToMove != WhiteTeam {
+		t.Errorf("Got %v to move, want White", pos.ToMove)
+	}
+	if pos.Board.Castling != WhiteKingside|BlackQueenside {
+		t.Errorf("Got castling rights %v, want White kingside and Black queenside", pos.Board.Castling)
+	}
+	if target, ok := pos.EnPassantTarget(); !ok || target != NotationToCoord("d6") {
+		t.Errorf("Got en passant target %v (%v), want d6", target, ok)
+	}
+	if pos.HalfMoves != 7 || pos.MoveNumber != 31 {
+		t.Errorf("Got halfmove clock %d and fullmove number %d, want 7 and 31", pos.HalfMoves, pos.MoveNumber)
+	}
+
+	// The counters may be left out, as in EPD
+	pos, err = ParseFEN("4k3/8/8/8/8/8/8/4K3 w - -")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos.HalfMoves != 0 || pos.MoveNumber != 1 {
+		t.Errorf("Got halfmove clock %d and fullmove number %d, want 0 and 1", pos.HalfMoves, pos.MoveNumber)
+	}
+}
+
+func TestParseFENErrors(t *testing.T) {
+	for _, fen := range []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR/8 w KQkq - 0 1",
+		"rnbqkbnr/ppppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/ppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnx/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e4 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq i3 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 0",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - x 1",
+	} {
+		if _, err := ParseFEN(fen); err == nil {
+			t.Errorf("%q: expected an error", fen)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package chess
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // A Game represents a chess game.
@@ -15,10 +16,93 @@ type Game struct {
 
 // Reset resets the game. The board is set to the initial state and
 // it is as if no moves have been played. This allows the game to
-// be replayed.
-func (g *Game) Reset() {
-	g.Board.Setup()
+// be replayed. The initial state is given by the FEN tag, if any;
+// an error is returned if it can't be parsed.
+func (g *Game) Reset() error {
 	g.moveIdx = 0
+
+	pos, err := g.StartPosition()
+	if err != nil {
+		g.Board.Setup()
+		return err
+	}
+	g.Board = pos.Board
+
+	return nil
+}
+
+// StartPosition returns the position the game starts from. This
+// is the standard initial position unless the game has a FEN tag,
+// which is used as long as the SetUp tag is not "0".
+func (g Game) StartPosition() (Position, error) {
+	fen, ok := g.Tags["FEN"]
+	if !ok || g.Tags["SetUp"] == "0" {
+		return startPosition, nil
+	}
+	return ParseFEN(fen)
+}
+
+// startPosition is the standard initial position,
+// parsed once since nearly every game uses it.
+var startPosition, _ = ParseFEN(StartFEN)
+
+// Position returns the current position of the game, which
+// reflects the moves that have been executed so far.
+func (g Game) Position() Position {
+	start, _ := g.StartPosition()
+
+	pos := Position{
+		Board:      g.Board,
+		ToMove:     g.ToMove(),
+		MoveNumber: start.MoveNumber,
+		HalfMoves:  start.HalfMoves,
+	}
+
+	if g.moveIdx > 0 {
+		last := g.Moves[g.moveIdx-1]
+		pos.MoveNumber = last.Number
+		if last.PlayerColor == BlackTeam {
+			pos.MoveNumber++
+		}
+	}
+
+	// Count plies back to the last capture or pawn move
+	for i := g.moveIdx - 1; i >= 0; i-- {
+		t := g.Moves[i].Text
+		if t != "" && isFile[t[0]] || strings.ContainsAny(t, "x:") {
+			pos.HalfMoves = g.moveIdx - 1 - i
+			break
+		}
+		if i == 0 {
+			pos.HalfMoves += g.moveIdx
+		}
+	}
+
+	return pos
+}
+
+// Ply returns the number of moves that have been
+// executed so far.
+func (g Game) Ply() int {
+	return g.moveIdx
+}
+
+// ToMove returns the color of the player whose turn it is.
+func (g Game) ToMove() Color {
+	if g.moveIdx < len(g.Moves) {
+		return g.Moves[g.moveIdx].PlayerColor
+	}
+	if len(g.Moves) > 0 {
+		if g.Moves[len(g.Moves)-1].PlayerColor == WhiteTeam {
+			return BlackTeam
+		}
+		return WhiteTeam
+	}
+	pos, err := g.StartPosition()
+	if err != nil {
+		return WhiteTeam
+	}
+	return pos.ToMove
 }
 
 // Execute plays n moves of the game or until the game
@@ -37,7 +121,7 @@ func (g *Game) Execute(n int) error {
 		move := g.Moves[g.moveIdx]
 		err := g.move(move)
 		if err != nil {
-			return fmt.Errorf("Turn %d %s, move %d ('%s') - %s", g.moveIdx/2+int(move.PlayerColor), move.Player, g.moveIdx, move.Text, err)
+			return fmt.Errorf("Turn %d %s, move %d ('%s') - %s", move.Number, move.Player, g.moveIdx, move.Text, err)
 		}
 		g.moveIdx++
 	}
@@ -47,21 +131,40 @@ func (g *Game) Execute(n int) error {
 
 // move executes the move m.
 func (g *Game) move(m Move) error {
-	pm, err := m.Parse()
+	pm, from, err := g.locate(m)
 	if err != nil {
+		if pm != nil {
+			// The move was understood, but no piece can make it
+			return fmt.Errorf("%s in position %s (parsed move: %+v)", err, g.Position().FEN(), *pm)
+		}
 		return err
 	}
+	return g.apply(pm, from)
+}
+
+// locate parses the move m and finds the piece that makes it,
+// returning the parsed move and the piece's position. If no
+// piece can make the move, the parsed move is still returned
+// along with the error.
+func (g *Game) locate(m Move) (*ParsedMove, Coord, error) {
+	pm, err := m.Parse()
+	if err != nil {
+		return nil, Coord{}, err
+	}
 
 	// Find the piece that can satisfy the move
 	_, row, col, found := g.findPiece(pm)
 	if !found {
-		fmt.Println(g.Board)
-		fmt.Printf("Parsed Move: %#v\n", pm)
-		return errors.New("Couldn't find any piece to satisfy the move '" + m.Text + "'")
+		return pm, Coord{}, errors.New("Couldn't find any piece to satisfy the move '" + m.Text + "'")
 	}
 
-	// Departure coordinate
-	from := Coord{Row: row, Col: col}
+	return pm, Coord{Row: row, Col: col}, nil
+}
+
+// apply moves the piece at from as described by pm, which
+// must have been checked with findPiece.
+func (g *Game) apply(pm *ParsedMove, from Coord) error {
+	row, col := from.Row, from.Col
 
 	// Handle castles a little differently
 	if pm.Castle == KingsideCastle {
@@ -78,7 +181,7 @@ func (g *Game) move(m Move) error {
 	to := NotationToCoord(pm.Destination)
 
 	// Execute the move
-	_, err = g.Board.MovePiece(from, to)
+	_, err := g.Board.MovePiece(from, to)
 	if err != nil {
 		return err
 	}
@@ -103,6 +206,20 @@ func (g *Game) move(m Move) error {
 // (non-zero values). If the piece was found, it returns that piece
 // and its row,col position, and true. Otherwise, returns false.
 func (g *Game) findPiece(pm *ParsedMove) (Piece, int, int, bool) {
+	found := g.findPieces(pm, false)
+	if len(found) == 0 {
+		return Piece{}, -1, -1, false
+	}
+	c := found[0]
+	return g.Board.Spaces[c.Row][c.Col], c.Row, c.Col, true
+}
+
+// findPieces returns the positions of the pieces that can satisfy
+// the move specified, like findPiece. If all is false, it stops
+// after the first one.
+func (g *Game) findPieces(pm *ParsedMove, all bool) []Coord {
+	var found []Coord
+
 	departRow := rankToRow[pm.DepartureRank]
 	departCol := fileToCol[pm.DepartureFile]
 	destRow := rankToRow[pm.DestinationRank]
@@ -128,7 +245,7 @@ func (g *Game) findPiece(pm *ParsedMove) (Piece, int, int, bool) {
 			// Handle castling moves a little differently
 			// TODO: Check to make sure the castling is possible/allowed?
 			if pm.Castle != "" {
-				return piece, row, col, true
+				return append(found, Coord{Row: row, Col: col})
 			}
 
 			if movePossible(g.Board, piece, row, col, destRow, destCol) {
@@ -152,10 +269,13 @@ func (g *Game) findPiece(pm *ParsedMove) (Piece, int, int, bool) {
 					continue // Not allowed; find another piece
 				}
 
-				return piece, row, col, true
+				found = append(found, Coord{Row: row, Col: col})
+				if !all {
+					return found
+				}
 			}
 		}
 	}
 
-	return Piece{}, -1, -1, false
+	return found
 }
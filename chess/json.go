@@ -0,0 +1,212 @@
+package chess
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
+
+// MarshalJSON encodes the game as a JSON object like this:
+//
+//	{
+//	  "tags": {"White": "Tal, Mikhail", "Result": "1-0", ...},
+//	  "moves": [
+//	    {"ply": 0, "number": 1, "color": "white", "san": "e4", "uci": "e2e4",
+//	     "comment": "Best by test", "nags": [1], "clock": 296.5,
+//	     "elapsed": 3.5, "eval": {"cp": 23}},
+//	    ...
+//	  ],
+//	  "played": 12
+//	}
+//
+// Clock and elapsed times are in seconds. An eval has either
+// "cp", centipawns, or "mate", moves to mate; both are from
+// White's point of view. Fields without a value are left out.
+// The UCI form of each move is found by replaying the game, so
+// it is missing from an illegal move and the moves after it.
+// Played is the number of moves executed so far, if any.
+func (g Game) MarshalJSON() ([]byte, error) {
+	out := gameJSON{
+		Tags:   g.Tags,
+		Moves:  make([]moveJSON, len(g.Moves)),
+		Played: g.moveIdx,
+	}
+	if out.Tags == nil {
+		out.Tags = map[string]string{}
+	}
+
+	for i, m := range g.Moves {
+		mj := moveJSON{
+			Ply:     i,
+			Number:  m.Number,
+			Color:   colorNames[m.PlayerColor],
+			SAN:     m.Text,
+			Comment: m.Comment,
+			NAGs:    m.NAGs,
+			Clock:   durationSeconds(m.Clock),
+			Elapsed: durationSeconds(m.Elapsed),
+		}
+		if m.Eval != nil {
+			score := m.Eval.Score
+			if m.Eval.Mate {
+				mj.Eval = &evalJSON{Mate: &score}
+			} else {
+				mj.Eval = &evalJSON{CP: &score}
+			}
+		}
+		out.Moves[i] = mj
+	}
+
+	// The error doesn't matter; moves without UCI are left out
+	g.Replay(func(pos Position, m PlayedMove) error {
+		out.Moves[m.Ply].UCI = m.UCI()
+		return nil
+	})
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a game encoded by MarshalJSON. The
+// moves are read from their SAN; their UCI form is ignored.
+// The moves that had been played are executed again.
+func (g *Game) UnmarshalJSON(data []byte) error {
+	var in gameJSON
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return err
+	}
+
+	game := Game{Tags: in.Tags}
+	if game.Tags == nil {
+		game.Tags = make(map[string]string)
+	}
+
+	for _, mj := range in.Moves {
+		m := Move{
+			Number:  mj.Number,
+			Text:    mj.SAN,
+			Comment: mj.Comment,
+			NAGs:    mj.NAGs,
+			Clock:   secondsDuration(mj.Clock),
+			Elapsed: secondsDuration(mj.Elapsed),
+		}
+
+		switch mj.Color {
+		case colorNames[WhiteTeam]:
+			m.Player, m.PlayerColor = White, WhiteTeam
+		case colorNames[BlackTeam]:
+			m.Player, m.PlayerColor = Black, BlackTeam
+		default:
+			return errors.New("Bad move color '" + mj.Color + "'")
+		}
+		if m.Text == "" {
+			return errors.New("Move has no SAN")
+		}
+
+		if mj.Eval != nil {
+			switch {
+			case mj.Eval.Mate != nil:
+				m.Eval = &Eval{Mate: true, Score: *mj.Eval.Mate}
+			case mj.Eval.CP != nil:
+				m.Eval = &Eval{Score: *mj.Eval.CP}
+			}
+		}
+
+		game.Moves = append(game.Moves, m)
+	}
+
+	err = game.Reset()
+	if err != nil {
+		return err
+	}
+	err = game.Execute(in.Played)
+	if err != nil {
+		return err
+	}
+
+	*g = game
+	return nil
+}
+
+// MarshalJSON encodes the position as a JSON object with
+// its FEN and the color to move, like this:
+//
+//	{"fen": "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", "toMove": "black"}
+func (p Position) MarshalJSON() ([]byte, error) {
+	return json.Marshal(positionJSON{FEN: p.FEN(), ToMove: colorNames[p.ToMove]})
+}
+
+// UnmarshalJSON decodes a position from its FEN; the color to
+// move is also given by the FEN, so the toMove field is ignored.
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var in positionJSON
+	err := json.Unmarshal(data, &in)
+	if err != nil {
+		return err
+	}
+
+	pos, err := ParseFEN(in.FEN)
+	if err != nil {
+		return err
+	}
+
+	*p = pos
+	return nil
+}
+
+// durationSeconds converts d to seconds, if it is not nil.
+func durationSeconds(d *time.Duration) *float64 {
+	if d == nil {
+		return nil
+	}
+	secs := d.Seconds()
+	return &secs
+}
+
+// secondsDuration converts seconds to a duration, if not nil.
+func secondsDuration(secs *float64) *time.Duration {
+	if secs == nil {
+		return nil
+	}
+	d := time.Duration(math.Round(*secs * float64(time.Second)))
+	return &d
+}
+
+// JSON forms of games and positions
+type (
+	gameJSON struct {
+		Tags   map[string]string `json:"tags"`
+		Moves  []moveJSON        `json:"moves"`
+		Played int               `json:"played,omitempty"`
+	}
+
+	moveJSON struct {
+		Ply     int       `json:"ply"`
+		Number  int       `json:"number"`
+		Color   string    `json:"color"`
+		SAN     string    `json:"san"`
+		UCI     string    `json:"uci,omitempty"`
+		Comment string    `json:"comment,omitempty"`
+		NAGs    []int     `json:"nags,omitempty"`
+		Clock   *float64  `json:"clock,omitempty"`
+		Elapsed *float64  `json:"elapsed,omitempty"`
+		Eval    *evalJSON `json:"eval,omitempty"`
+	}
+
+	evalJSON struct {
+		CP   *int `json:"cp,omitempty"`
+		Mate *int `json:"mate,omitempty"`
+	}
+
+	positionJSON struct {
+		FEN    string `json:"fen"`
+		ToMove string `json:"toMove"`
+	}
+)
+
+// colorNames are the names of the colors in JSON.
+var colorNames = map[Color]string{
+	WhiteTeam: "white",
+	BlackTeam: "black",
+}
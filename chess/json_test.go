@@ -0,0 +1,79 @@
+package chess
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGameJSONRoundTrip(t *testing.T) {
+	clock := 95*time.Second + 500*time.Millisecond
+	elapsed := 4 * time.Second
+
+	game := Game{
+		Tags: map[string]string{
+			"Event":  "Test",
+			"White":  "Tal, Mikhail",
+			"Black":  "Botvinnik, Mikhail",
+			"Result": "*",
+			"SetUp":  "1",
+			"FEN":    "r3k2r/1P6/8/3pP3/8/8/8/R3K2R w KQkq d6 0 20",
+		},
+		Moves: []Move{
+			{Player: White, PlayerColor: WhiteTeam, Number: 20, Text: "exd6", Comment: "En passant", NAGs: []int{1}},
+			{Player: Black, PlayerColor: BlackTeam, Number: 20, Text: "O-O", Clock: &clock, Elapsed: &elapsed},
+			{Player: White, PlayerColor: WhiteTeam, Number: 21, Text: "bxa8=Q", NAGs: []int{3, 14}, Eval: &Eval{Score: 850}},
+			{Player: Black, PlayerColor: BlackTeam, Number: 21, Text: "Rxa8", Eval: &Eval{Mate: true, Score: -5}},
+			{Player: White, PlayerColor: WhiteTeam, Number: 22, Text: "O-O-O"},
+		},
+	}
+	if err := game.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := game.Execute(3); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uci := range []string{`"e5d6"`, `"e8g8"`, `"b7a8q"`, `"f8a8"`, `"e1c1"`} {
+		if !strings.Contains(string(data), uci) {
+			t.Errorf("JSON is missing the move %s: %s", uci, data)
+		}
+	}
+
+	var decoded Game
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, game) {
+		t.Errorf("Game changed in JSON round trip:\ngot  %+v\nwant %+v", decoded, game)
+	}
+}
+
+func TestPositionJSONRoundTrip(t *testing.T) {
+	for _, fen := range []string{
+		StartFEN,
+		"r3k2r/8/8/3pP3/8/8/8/R3K2R w Kq d6 0 20",
+	} {
+		pos, err := ParseFEN(fen)
+		if err != nil {
+			t.Fatalf("%s: %v", fen, err)
+		}
+		data, err := json.Marshal(pos)
+		if err != nil {
+			t.Fatalf("%s: %v", fen, err)
+		}
+		var decoded Position
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %v", fen, err)
+		}
+		if got := decoded.FEN(); got != fen {
+			t.Errorf("Got FEN %s after JSON round trip, want %s", got, fen)
+		}
+	}
+}
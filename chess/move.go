@@ -3,6 +3,7 @@ package chess
 import (
 	"errors"
 	"strings"
+	"time"
 )
 
 // A Move represents a move by a single player in
@@ -10,7 +11,30 @@ import (
 type Move struct {
 	Player      string
 	PlayerColor Color
+	Number      int // number of the turn, as in the movetext
 	Text        string
+
+	// Comment is the text of any comments following the
+	// move, with embedded commands like [%clk ...] removed.
+	Comment string
+
+	// NAGs are the Numeric Annotation Glyphs following the
+	// move, like 1 for "!" (good move) or 14 for "+=".
+	NAGs []int
+
+	// Values of commands embedded in the comments, if any.
+	// They are nil if the command was not present.
+	Clock   *time.Duration // [%clk] time remaining on the player's clock
+	Elapsed *time.Duration // [%emt] time the player spent on this move
+	Eval    *Eval          // [%eval] engine evaluation after this move
+}
+
+// An Eval is an engine evaluation of a position. Scores
+// are always from White's point of view: positive favors
+// White and negative favors Black.
+type Eval struct {
+	Mate  bool // whether the score is a forced mate
+	Score int  // centipawns, or number of moves until mate if Mate is true
 }
 
 // A parsed move represents movetext that is more usable
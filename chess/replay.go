@@ -0,0 +1,74 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A PlayedMove is a move as it was played on the board:
+// which square the piece moved from and to.
+type PlayedMove struct {
+	Ply       int    // index of the move in the game's Moves
+	From, To  Coord  // the king's squares, for castling
+	Promotion Rank   // what a pawn was promoted to, if anything
+	Castle    string // KingsideCastle or QueensideCastle, if castling
+	EnPassant bool
+}
+
+// UCI returns the move in the long algebraic notation used by
+// the Universal Chess Interface, like e2e4, e7e8q or e1g1.
+func (m PlayedMove) UCI() string {
+	s := strings.ToLower(CoordToNotation(m.From) + CoordToNotation(m.To))
+	if m.Promotion != Empty {
+		s += string(pieceToFEN(Piece{Color: BlackTeam, Rank: m.Promotion}))
+	}
+	return s
+}
+
+// Replay plays the game from its start position, calling fn with
+// the position before each move and the move as played. The game
+// itself is not changed. If fn returns an error, replaying stops
+// and the error is returned.
+func (g Game) Replay(fn func(pos Position, m PlayedMove) error) error {
+	err := g.Reset()
+	if err != nil {
+		return err
+	}
+
+	for g.moveIdx < len(g.Moves) {
+		move := g.Moves[g.moveIdx]
+		pm, from, err := g.locate(move)
+		if err != nil {
+			return fmt.Errorf("Turn %d %s, move %d ('%s') - %s", move.Number, move.Player, g.moveIdx, move.Text, err)
+		}
+
+		played := PlayedMove{
+			Ply:       g.moveIdx,
+			From:      from,
+			Promotion: pm.PawnPromotion,
+			Castle:    pm.Castle,
+			EnPassant: pm.EnPassant,
+		}
+		switch pm.Castle {
+		case KingsideCastle:
+			played.To = Coord{Row: from.Row, Col: from.Col + 2}
+		case QueensideCastle:
+			played.To = Coord{Row: from.Row, Col: from.Col - 2}
+		default:
+			played.To = NotationToCoord(pm.Destination)
+		}
+
+		err = fn(g.Position(), played)
+		if err != nil {
+			return err
+		}
+
+		err = g.apply(pm, from)
+		if err != nil {
+			return fmt.Errorf("Turn %d %s, move %d ('%s') - %s", move.Number, move.Player, g.moveIdx, move.Text, err)
+		}
+		g.moveIdx++
+	}
+
+	return nil
+}
@@ -0,0 +1,347 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date returns the date of the game from its Date tag. Parts of
+// the date that are unknown are zero; a game without the tag has
+// the zero Date.
+func (g Game) Date() (Date, error) {
+	return ParseDate(g.Tags["Date"])
+}
+
+// WhiteElo returns White's rating from the WhiteElo tag,
+// or 0 if it is missing or unknown.
+func (g Game) WhiteElo() (int, error) {
+	return parseElo(g.Tags, "WhiteElo")
+}
+
+// BlackElo returns Black's rating from the BlackElo tag,
+// or 0 if it is missing or unknown.
+func (g Game) BlackElo() (int, error) {
+	return parseElo(g.Tags, "BlackElo")
+}
+
+// parseElo parses the rating in the named tag. Unrated
+// players are often given "-" instead of a number.
+func parseElo(tags map[string]string, name string) (int, error) {
+	v := tags[name]
+	if isUnknownTag(v) || v == "-" {
+		return 0, nil
+	}
+	elo, err := strconv.Atoi(v)
+	if err != nil || elo < 0 {
+		return 0, fmt.Errorf("Bad %s tag '%s'", name, v)
+	}
+	return elo, nil
+}
+
+// Round returns the round of the game from its Round tag. A
+// round may have parts, like 3.1 for the first game of the third
+// round, so each part is an element of the slice. The round is
+// nil if it is unknown or doesn't apply ("-").
+func (g Game) Round() ([]int, error) {
+	v := g.Tags["Round"]
+	if isUnknownTag(v) || v == "-" {
+		return nil, nil
+	}
+
+	var round []int
+	for _, part := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Bad Round tag '%s'", v)
+		}
+		round = append(round, n)
+	}
+	return round, nil
+}
+
+// TimeControl returns the time control of the game from its
+// TimeControl tag. It has no stages if it is unknown.
+func (g Game) TimeControl() (TimeControl, error) {
+	return ParseTimeControl(g.Tags["TimeControl"])
+}
+
+// Termination returns how the game ended, from the
+// Termination tag.
+func (g Game) Termination() (Termination, error) {
+	v := g.Tags["Termination"]
+	if isUnknownTag(v) {
+		return UnknownTermination, nil
+	}
+	for t, name := range terminationNames {
+		if strings.EqualFold(v, name) {
+			return Termination(t), nil
+		}
+	}
+	return UnknownTermination, fmt.Errorf("Bad Termination tag '%s'", v)
+}
+
+// Result returns the result of the game from the Result tag.
+func (g Game) Result() (Result, error) {
+	v := g.Tags["Result"]
+	switch v {
+	case WhiteWin:
+		return ResultWhiteWin, nil
+	case BlackWin:
+		return ResultBlackWin, nil
+	case Draw:
+		return ResultDraw, nil
+	case Other, "", "?":
+		return ResultUnknown, nil
+	}
+	return ResultUnknown, fmt.Errorf("Bad Result tag '%s'", v)
+}
+
+// isUnknownTag returns whether v is the value of a tag
+// that is missing or marked unknown.
+func isUnknownTag(v string) bool {
+	return v == "" || v == "?"
+}
+
+// A Date is a date as given in PGN tags. Each part is
+// zero if it is not known.
+type Date struct {
+	Year, Month, Day int
+}
+
+// ParseDate parses a date in PGN format, YYYY.MM.DD, where unknown
+// parts are given as question marks, like 2013.05.??. The month and
+// day may be left off entirely, as in 2013.
+func ParseDate(s string) (Date, error) {
+	var d Date
+	if isUnknownTag(s) {
+		return d, nil
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return d, fmt.Errorf("Bad date '%s'", s)
+	}
+
+	fields := []*int{&d.Year, &d.Month, &d.Day}
+	limits := []int{9999, 12, 31}
+	for i, part := range parts {
+		if part != "" && strings.Trim(part, "?") == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > limits[i] {
+			return Date{}, fmt.Errorf("Bad date '%s'", s)
+		}
+		*fields[i] = n
+	}
+
+	return d, nil
+}
+
+// Compare compares d with other, returning -1 if d is earlier,
+// 1 if it is later, and 0 otherwise. Dates are compared only as
+// far as both are known, so 2013.??.?? is the same as 2013.05.12.
+func (d Date) Compare(other Date) int {
+	a := []int{d.Year, d.Month, d.Day}
+	b := []int{other.Year, other.Month, other.Day}
+	for i := range a {
+		if a[i] == 0 || b[i] == 0 {
+			break
+		}
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsZero returns whether no part of the date is known.
+func (d Date) IsZero() bool {
+	return d.Year == 0 && d.Month == 0 && d.Day == 0
+}
+
+// String returns the date in PGN format.
+func (d Date) String() string {
+	part := func(n, width int) string {
+		if n == 0 {
+			return strings.Repeat("?", width)
+		}
+		return fmt.Sprintf("%0*d", width, n)
+	}
+	return part(d.Year, 4) + "." + part(d.Month, 2) + "." + part(d.Day, 2)
+}
+
+// A TimeControl is the time allowed for a game, which is
+// played in one or more stages.
+type TimeControl struct {
+	Stages  []TimeStage
+	Untimed bool // whether the game was played without a clock ("-")
+}
+
+// A TimeStage is one stage of a time control: the time
+// for a number of moves, or for the rest of the game.
+type TimeStage struct {
+	Moves     int           // moves to be made in the stage, or 0 for the rest of the game
+	Base      time.Duration // time for the stage
+	Increment time.Duration // time added after each move
+	Sandclock bool          // whether the time is a sandclock (hourglass)
+}
+
+// ParseTimeControl parses a time control in the format of the
+// TimeControl tag: stages separated by colons, each of which is
+// seconds for the rest of the game ("300"), with an increment
+// ("300+3"), moves in seconds ("40/7200"), both ("40/5400+30"),
+// or a sandclock ("*180"). The value is "?" if unknown and "-" if untimed.
+func ParseTimeControl(s string) (TimeControl, error) {
+	var tc TimeControl
+	if isUnknownTag(s) {
+		return tc, nil
+	}
+	if s == "-" {
+		tc.Untimed = true
+		return tc, nil
+	}
+
+	bad := func() (TimeControl, error) {
+		return TimeControl{}, fmt.Errorf("Bad time control '%s'", s)
+	}
+	seconds := func(v string) (time.Duration, bool) {
+		n, err := strconv.Atoi(v)
+		return time.Duration(n) * time.Second, err == nil && n >= 0
+	}
+
+	for _, field := range strings.Split(s, ":") {
+		var stage TimeStage
+		var ok bool
+
+		if strings.HasPrefix(field, "*") {
+			stage.Sandclock = true
+			field = field[1:]
+		} else {
+			if i := strings.IndexByte(field, '/'); i > -1 {
+				stage.Moves, _ = strconv.Atoi(field[:i])
+				if stage.Moves < 1 {
+					return bad()
+				}
+				field = field[i+1:]
+			}
+			if i := strings.IndexByte(field, '+'); i > -1 {
+				stage.Increment, ok = seconds(field[i+1:])
+				if !ok {
+					return bad()
+				}
+				field = field[:i]
+			}
+		}
+
+		stage.Base, ok = seconds(field)
+		if !ok {
+			return bad()
+		}
+		tc.Stages = append(tc.Stages, stage)
+	}
+
+	return tc, nil
+}
+
+// Base returns the time for the first stage, or
+// 0 if the time control is unknown.
+func (tc TimeControl) Base() time.Duration {
+	if len(tc.Stages) == 0 {
+		return 0
+	}
+	return tc.Stages[0].Base
+}
+
+// Increment returns the increment of the first stage.
+func (tc TimeControl) Increment() time.Duration {
+	if len(tc.Stages) == 0 {
+		return 0
+	}
+	return tc.Stages[0].Increment
+}
+
+// Estimated returns the expected time each player has for a game
+// of 40 moves, which is the base time plus 40 increments. This is
+// how online sites sort games into bullet, blitz and so on.
+func (tc TimeControl) Estimated() time.Duration {
+	return tc.Base() + 40*tc.Increment()
+}
+
+// Termination is how a game ended, as in the Termination tag.
+type Termination int
+
+// Kinds of termination
+const (
+	UnknownTermination Termination = iota
+	Abandoned                      // abandoned by a player
+	Adjudication                   // result decided by a third party
+	Death                          // a player died
+	Emergency                      // game stopped by an emergency
+	Normal                         // ended by the rules of chess
+	RulesInfraction                // a player broke the rules
+	TimeForfeit                    // a player ran out of time
+	Unterminated                   // not yet over
+)
+
+func (t Termination) String() string {
+	if t < 0 || int(t) >= len(terminationNames) {
+		return "?"
+	}
+	return terminationNames[t]
+}
+
+// terminationNames are the values of the Termination tag,
+// indexed by Termination.
+var terminationNames = []string{
+	UnknownTermination: "?",
+	Abandoned:          "abandoned",
+	Adjudication:       "adjudication",
+	Death:              "death",
+	Emergency:          "emergency",
+	Normal:             "normal",
+	RulesInfraction:    "rules infraction",
+	TimeForfeit:        "time forfeit",
+	Unterminated:       "unterminated",
+}
+
+// Result is the outcome of a game.
+type Result int
+
+// Results of a game
+const (
+	ResultUnknown  Result = iota // game unfinished, abandoned, or unknown (*)
+	ResultWhiteWin               // 1-0
+	ResultBlackWin               // 0-1
+	ResultDraw                   // 1/2-1/2
+)
+
+// String returns the result as written in PGN.
+func (r Result) String() string {
+	switch r {
+	case ResultWhiteWin:
+		return WhiteWin
+	case ResultBlackWin:
+		return BlackWin
+	case ResultDraw:
+		return Draw
+	}
+	return Other
+}
+
+// Points returns the points scored by player c:
+// 1 for a win, 0.5 for a draw, and 0 otherwise.
+func (r Result) Points(c Color) float64 {
+	switch {
+	case r == ResultDraw:
+		return 0.5
+	case r == ResultWhiteWin && c == WhiteTeam, r == ResultBlackWin && c == BlackTeam:
+		return 1
+	}
+	return 0
+}
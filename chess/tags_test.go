@@ -0,0 +1,63 @@
+package chess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		want  TimeControl
+		ok    bool
+	}{
+		{"?", TimeControl{}, true},
+		{"", TimeControl{}, true},
+		{"-", TimeControl{Untimed: true}, true},
+		{"300", TimeControl{Stages: []TimeStage{{Base: 300 * time.Second}}}, true},
+		{"300+3", TimeControl{Stages: []TimeStage{{Base: 300 * time.Second, Increment: 3 * time.Second}}}, true},
+		{"40/7200", TimeControl{Stages: []TimeStage{{Moves: 40, Base: 7200 * time.Second}}}, true},
+		{"40/5400+30", TimeControl{Stages: []TimeStage{{Moves: 40, Base: 5400 * time.Second, Increment: 30 * time.Second}}}, true},
+		{"*180", TimeControl{Stages: []TimeStage{{Base: 180 * time.Second, Sandclock: true}}}, true},
+		{"40/5400+30:1800+30", TimeControl{Stages: []TimeStage{
+			{Moves: 40, Base: 5400 * time.Second, Increment: 30 * time.Second},
+			{Base: 1800 * time.Second, Increment: 30 * time.Second},
+		}}, true},
+		{"40/7200:20/3600:900", TimeControl{Stages: []TimeStage{
+			{Moves: 40, Base: 7200 * time.Second},
+			{Moves: 20, Base: 3600 * time.Second},
+			{Base: 900 * time.Second},
+		}}, true},
+		{"abc", TimeControl{}, false},
+		{"300+", TimeControl{}, false},
+		{"0/300", TimeControl{}, false},
+		{"40/", TimeControl{}, false},
+		{"40/300+x", TimeControl{}, false},
+		{"-300", TimeControl{}, false},
+		{"*180+2", TimeControl{}, false},
+	} {
+		got, err := ParseTimeControl(test.input)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %+v", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %+v, want %+v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestTimeControlEstimated(t *testing.T) {
+	tc, err := ParseTimeControl("40/5400+30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tc.Estimated(), 5400*time.Second+40*30*time.Second; got != want {
+		t.Errorf("Got estimated time %v, want %v", got, want)
+	}
+}
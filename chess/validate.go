@@ -0,0 +1,213 @@
+package chess
+
+import "fmt"
+
+// A Problem is something wrong with a game that was
+// found by replaying it.
+type Problem struct {
+	Ply  int    // index of the move in the game's Moves, or -1 if it's about the whole game
+	Move string // text of the move, if any
+	Kind ProblemKind
+	Msg  string
+}
+
+func (p Problem) String() string {
+	if p.Ply < 0 {
+		return p.Msg
+	}
+	return fmt.Sprintf("Move %d ('%s'): %s", p.Ply, p.Move, p.Msg)
+}
+
+// ProblemKind is a kind of problem with a game.
+type ProblemKind int
+
+// Kinds of problems
+const (
+	IllegalMove      ProblemKind = iota + 1 // no piece can legally make the move
+	AmbiguousMove                           // more than one piece can make the move
+	WrongCheckMarker                        // a check or checkmate marker doesn't match the position
+	WrongResult                             // the Result tag doesn't match the final position
+	BadSetup                                // the starting position can't be used
+)
+
+// Validate replays the game from its start position and reports
+// any problems found. The game itself is not changed. Replaying
+// stops at the first illegal move since the moves after it can't
+// be checked.
+func (g Game) Validate() []Problem {
+	var problems []Problem
+	report := func(ply int, kind ProblemKind, format string, args ...interface{}) {
+		var text string
+		if ply >= 0 {
+			text = g.Moves[ply].Text
+		}
+		problems = append(problems, Problem{Ply: ply, Move: text, Kind: kind, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	start, err := g.StartPosition()
+	if err != nil {
+		report(-1, BadSetup, "%v", err)
+		return problems
+	}
+	g.Board = start.Board
+	toMove := start.ToMove
+
+	for i, m := range g.Moves {
+		if m.PlayerColor != toMove {
+			report(i, IllegalMove, "Not %s's turn", m.Player)
+			return problems
+		}
+
+		pm, err := m.Parse()
+		if err != nil {
+			report(i, IllegalMove, "%v", err)
+			return problems
+		}
+
+		found := g.findPieces(pm, true)
+		if len(found) == 0 {
+			report(i, IllegalMove, "No piece can make this move")
+			return problems
+		}
+		if len(found) > 1 {
+			report(i, AmbiguousMove, "%d pieces can make this move", len(found))
+		}
+
+		if pm.Castle != "" && !canCastle(g.Board, pm.Color, pm.Castle) {
+			report(i, IllegalMove, "Castling is not allowed")
+			return problems
+		}
+
+		if pm.PieceType == Pawn && pm.PawnPromotion == Empty &&
+			(pm.DestinationRank == "1" || pm.DestinationRank == "8") {
+			report(i, IllegalMove, "Pawn reaches the last rank without promoting")
+			return problems
+		}
+
+		err = g.apply(pm, found[0])
+		if err != nil {
+			report(i, IllegalMove, "%v", err)
+			return problems
+		}
+
+		toMove = opponent(toMove)
+
+		check := NumCheckingKing(g.Board, toMove, false) > 0
+		mate := check && len(LegalMoves(g.Board, toMove)) == 0
+		switch {
+		case mate && !pm.Checkmate:
+			report(i, WrongCheckMarker, "Move gives checkmate but isn't marked with '#'")
+		case pm.Checkmate && !mate:
+			report(i, WrongCheckMarker, "Move is marked with '#' but isn't checkmate")
+		case check && !mate && !pm.Check:
+			report(i, WrongCheckMarker, "Move gives check but isn't marked with '+'")
+		case pm.Check && !check:
+			report(i, WrongCheckMarker, "Move is marked with '+' but isn't check")
+		}
+	}
+
+	// A finished game must have the only result its final position allows
+	result, ok := g.Tags["Result"]
+	if ok && len(LegalMoves(g.Board, toMove)) == 0 {
+		expected := Draw // stalemate
+		if NumCheckingKing(g.Board, toMove, false) > 0 {
+			expected = WhiteWin
+			if toMove == WhiteTeam {
+				expected = BlackWin
+			}
+		}
+		if result != expected {
+			report(-1, WrongResult, "Result is %s but the final position means %s", result, expected)
+		}
+	}
+
+	return problems
+}
+
+// LegalMoves returns the moves player c may make on board b,
+// which are the possible moves that don't leave c's king in
+// check, and any castles that are allowed.
+func LegalMoves(b Board, c Color) []ValidMove {
+	var legal []ValidMove
+
+	for row := 0; row < Size; row++ {
+		for col := 0; col < Size; col++ {
+			piece := b.Spaces[row][col]
+			if piece.Rank == Empty || piece.Color != c {
+				continue
+			}
+
+			for _, move := range PossibleMoves(b, piece, row, col, false) {
+				boardCopy := b.Copy()
+				boardCopy.MovePiece(move.From, move.To)
+				if move.EnPassant {
+					boardCopy.Spaces[move.From.Row][move.To.Col].Rank = Empty
+				}
+				if NumCheckingKing(boardCopy, c, false) == 0 {
+					legal = append(legal, move)
+				}
+			}
+
+			if piece.Rank == King {
+				if canCastle(b, c, KingsideCastle) {
+					legal = append(legal, ValidMove{From: Coord{row, col}, To: Coord{row, col + 2}})
+				}
+				if canCastle(b, c, QueensideCastle) {
+					legal = append(legal, ValidMove{From: Coord{row, col}, To: Coord{row, col - 2}})
+				}
+			}
+		}
+	}
+
+	return legal
+}
+
+// canCastle returns whether player c may castle to the given
+// side on board b: the king and rook have not moved, the
+// squares between them are empty, and the king is not in
+// check and does not pass through or land on an attacked square.
+func canCastle(b Board, c Color, castle string) bool {
+	row, right := 0, WhiteKingside
+	if c == BlackTeam {
+		row, right = 7, BlackKingside
+	}
+	rookCol, step := 7, 1
+	if castle == QueensideCastle {
+		rookCol, step = 0, -1
+		right <<= 1 // the queenside right follows the kingside right
+	}
+
+	king := b.Spaces[row][4]
+	rook := b.Spaces[row][rookCol]
+	if b.Castling&right == 0 ||
+		king.Rank != King || king.Color != c ||
+		rook.Rank != Rook || rook.Color != c {
+		return false
+	}
+
+	for col := 4 + step; col != rookCol; col += step {
+		if b.Spaces[row][col].Rank != Empty {
+			return false
+		}
+	}
+
+	for col := 4; col != 4+3*step; col += step {
+		boardCopy := b.Copy()
+		if col != 4 {
+			boardCopy.MovePiece(Coord{row, 4}, Coord{row, col})
+		}
+		if NumCheckingKing(boardCopy, c, false) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// opponent returns the other player's color.
+func opponent(c Color) Color {
+	if c == WhiteTeam {
+		return BlackTeam
+	}
+	return WhiteTeam
+}
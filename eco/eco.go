@@ -0,0 +1,214 @@
+// Package eco classifies chess games by their opening, using the
+// codes of the Encyclopaedia of Chess Openings. The table of
+// openings is from the lichess chess-openings project, which is
+// in the public domain (CC0).
+package eco
+
+import (
+	_ "embed"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/mholt/chessml/chess"
+	"github.com/mholt/chessml/polyglot"
+)
+
+// An Opening is one line of the ECO table.
+type Opening struct {
+	Code      string // like B33
+	Name      string // like Sicilian Defense
+	Variation string // like Sveshnikov Variation, or "" for the main line
+	Moves     string // the moves that define it, like "1. e4 c5 2. Nf3"
+	Ply       int    // the number of moves
+}
+
+// A Classification is the opening of a game.
+type Classification struct {
+	// Opening is the deepest opening the game reaches,
+	// or nil if it doesn't reach any in the table.
+	Opening *Opening
+
+	// Ply is the number of moves the game had played when
+	// it reached the opening. Since openings are matched by
+	// position, this may differ from the opening's own Ply.
+	Ply int
+
+	// LeftTheory is the number of moves the game had played
+	// when it first reached a position that is not in the table
+	// or on the way to one: 0 if it starts out of theory. It is
+	// -1 if the game never leaves theory. Games may transpose
+	// back into theory later; the opening is still the deepest
+	// one reached.
+	LeftTheory int
+}
+
+// Classify replays the game and finds the openings it reaches.
+// Openings are matched by position, not by the order of the
+// moves, so transpositions are recognized.
+func Classify(game chess.Game) (Classification, error) {
+	table, err := loadTable()
+	if err != nil {
+		return Classification{}, err
+	}
+
+	c := Classification{LeftTheory: -1}
+	err = eachPosition(game, func(ply int, pos chess.Position) {
+		key := polyglot.Key(pos)
+		if opening, ok := table.openings[key]; ok {
+			c.Opening, c.Ply = opening, ply
+		}
+		if !table.theory[key] && c.LeftTheory < 0 {
+			c.LeftTheory = ply
+		}
+	})
+	return c, err
+}
+
+// eachPosition plays the game from its start position, calling
+// fn with the number of moves played and the position after
+// them, from the start position through the final one.
+func eachPosition(game chess.Game, fn func(ply int, pos chess.Position)) error {
+	err := game.Reset()
+	if err != nil {
+		return err
+	}
+	for ply := 0; ; ply++ {
+		fn(ply, game.Position())
+		if ply == len(game.Moves) {
+			return nil
+		}
+		err = game.Execute(1)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// SetTags writes the classification into the ECO, Opening and
+// Variation tags of a game, replacing any there were. If the
+// game reached no opening, the tags are removed.
+func (c Classification) SetTags(tags map[string]string) {
+	delete(tags, "ECO")
+	delete(tags, "Opening")
+	delete(tags, "Variation")
+
+	if c.Opening == nil {
+		return
+	}
+	tags["ECO"] = c.Opening.Code
+	tags["Opening"] = c.Opening.Name
+	if c.Opening.Variation != "" {
+		tags["Variation"] = c.Opening.Variation
+	}
+}
+
+// Lookup returns the opening whose moves end in the position,
+// if there is one.
+func Lookup(pos chess.Position) (*Opening, error) {
+	table, err := loadTable()
+	if err != nil {
+		return nil, err
+	}
+	return table.openings[polyglot.Key(pos)], nil
+}
+
+// loadTable parses the ECO table the first time it is needed.
+// Where two openings end in the same position, the first one
+// in the table is used.
+func loadTable() (*ecoTable, error) {
+	tableOnce.Do(func() {
+		table = &ecoTable{
+			openings: make(map[uint64]*Opening),
+			theory:   make(map[uint64]bool),
+		}
+
+		lines := strings.Split(strings.TrimSpace(ecoTSV), "\n")
+		for _, line := range lines[1:] { // skip the header
+			fields := strings.Split(line, "\t")
+			if len(fields) != 4 {
+				tableErr = errors.New("Bad line in ECO table: '" + line + "'")
+				return
+			}
+
+			opening := &Opening{
+				Code:      fields[0],
+				Name:      fields[1],
+				Variation: fields[2],
+				Moves:     fields[3],
+			}
+
+			game := movesToGame(opening.Moves)
+			opening.Ply = len(game.Moves)
+
+			// Every position on the way to the opening is theory
+			var key uint64
+			err := eachPosition(game, func(ply int, pos chess.Position) {
+				key = polyglot.Key(pos)
+				table.theory[key] = true
+			})
+			if err != nil {
+				tableErr = errors.New("Bad moves in ECO table for " + opening.Code + " " + opening.Name + ": " + err.Error())
+				return
+			}
+
+			if _, ok := table.openings[key]; !ok {
+				table.openings[key] = opening
+			}
+		}
+	})
+	return table, tableErr
+}
+
+// ecoTable is the parsed table of openings.
+type ecoTable struct {
+	openings map[uint64]*Opening // by the key of the opening's position
+	theory   map[uint64]bool     // keys of all positions on the way to an opening
+}
+
+// movesToGame makes a game from the movetext of an opening,
+// which has only move numbers and moves.
+func movesToGame(movetext string) chess.Game {
+	game := chess.Game{Tags: make(map[string]string)}
+	game.Reset()
+
+	color := chess.WhiteTeam
+	number := 1
+	for _, text := range strings.Fields(movetext) {
+		if strings.HasSuffix(text, ".") {
+			continue
+		}
+
+		player := chess.White
+		if color == chess.BlackTeam {
+			player = chess.Black
+		}
+		game.Moves = append(game.Moves, chess.Move{
+			Player:      player,
+			PlayerColor: color,
+			Number:      number,
+			Text:        text,
+		})
+
+		if color == chess.WhiteTeam {
+			color = chess.BlackTeam
+		} else {
+			color = chess.WhiteTeam
+			number++
+		}
+	}
+
+	return game
+}
+
+var (
+	tableOnce sync.Once
+	table     *ecoTable
+	tableErr  error
+)
+
+// ecoTSV is the table of openings, with columns for the
+// code, name, variation and moves of each.
+//
+//go:embed eco.tsv
+var ecoTSV string
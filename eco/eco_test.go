@@ -0,0 +1,69 @@
+package eco
+
+import (
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestClassify(t *testing.T) {
+	for _, test := range []struct {
+		moves      string
+		code, name string
+		ply        int
+		leftTheory int
+	}{
+		{"1. e4 e5 2. Nf3 Nc6 3. Bb5", "C60", "Ruy Lopez", 5, -1},
+		{"1. Nf3 Nc6 2. e4 e5 3. Bb5", "C60", "Ruy Lopez", 5, -1},
+		{"1. e4 e5 2. Nf3 Nc6 3. Bb5 Qh4 4. Nxh4", "C60", "Ruy Lopez", 5, 6},
+		{"1. e4 e5 2. Qh5 Qh4 3. Qd1 Qd8 4. Nf3 Nc6 5. Bb5", "C60", "Ruy Lopez", 9, 4},
+		{"", "", "", 0, -1},
+	} {
+		c, err := Classify(movesToGame(test.moves))
+		if err != nil {
+			t.Errorf("%s: %v", test.moves, err)
+			continue
+		}
+		var code, name string
+		if c.Opening != nil {
+			code, name = c.Opening.Code, c.Opening.Name
+		}
+		if code != test.code || name != test.name || c.Ply != test.ply || c.LeftTheory != test.leftTheory {
+			t.Errorf("%s: got %s %s at ply %d, left theory at %d; want %s %s at ply %d, left theory at %d",
+				test.moves, code, name, c.Ply, c.LeftTheory, test.code, test.name, test.ply, test.leftTheory)
+		}
+	}
+}
+
+func TestClassifyOutOfTheory(t *testing.T) {
+	game := chess.Game{Tags: map[string]string{
+		"SetUp": "1",
+		"FEN":   "4k3/8/8/8/8/8/8/4K3 w - - 0 1",
+	}}
+	game.Moves = []chess.Move{{Player: chess.White, PlayerColor: chess.WhiteTeam, Number: 1, Text: "Ke2"}}
+
+	c, err := Classify(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Opening != nil || c.LeftTheory != 0 {
+		t.Errorf("Got opening %v, left theory at %d; want none, left theory at 0", c.Opening, c.LeftTheory)
+	}
+}
+
+func TestSetTags(t *testing.T) {
+	c, err := Classify(movesToGame("1. e4 e5 2. Nf3 Nc6 3. Bb5 Bb4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := map[string]string{"ECO": "A00", "Variation": "Old"}
+	c.SetTags(tags)
+	if tags["ECO"] != "C60" || tags["Opening"] != "Ruy Lopez" || tags["Variation"] != "Alapin Defense" {
+		t.Errorf("Got tags %v, want C60 Ruy Lopez, Alapin Defense", tags)
+	}
+
+	Classification{}.SetTags(tags)
+	if len(tags) != 0 {
+		t.Errorf("Got tags %v, want none", tags)
+	}
+}
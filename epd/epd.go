@@ -0,0 +1,356 @@
+// Package epd reads and writes Extended Position Description
+// records, which describe a position and its operations, like
+// the best move. Test suites of positions are kept in EPD files.
+package epd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// A Record is one line of an EPD file: a position and
+// the operations that describe it.
+type Record struct {
+	Position chess.Position
+	Ops      Ops
+}
+
+// Parse parses a single EPD record. The first four fields are
+// the same as in FEN; the operations after them each consist of
+// an opcode, zero or more operands, and a semicolon, like:
+//
+//	r1b1k2r/ppppnppp/2n2q2/2b5/3NP3/2P1B3/PP3PPP/RN1QKB1R w KQkq - bm Nxc6; id "test.1";
+//
+// The hmvc and fmvn operations, if present, set the halfmove
+// clock and move number of the position.
+func Parse(line string) (Record, error) {
+	var rec Record
+
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return rec, errors.New("EPD record must have at least 4 fields; got: '" + line + "'")
+	}
+
+	var err error
+	rec.Position, err = chess.ParseFEN(strings.Join(fields[:4], " "))
+	if err != nil {
+		return rec, err
+	}
+
+	// The operations are whatever follows the fourth field
+	rest := line
+	for i := 0; i < 4; i++ {
+		rest = strings.TrimLeft(rest, " \t")
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		rest = rest[end:]
+	}
+
+	rec.Ops, err = parseOps(rest)
+	if err != nil {
+		return rec, err
+	}
+
+	if _, ok := rec.Ops["hmvc"]; ok {
+		rec.Position.HalfMoves, err = rec.Ops.Int("hmvc")
+		if err != nil {
+			return rec, err
+		}
+	}
+	if _, ok := rec.Ops["fmvn"]; ok {
+		rec.Position.MoveNumber, err = rec.Ops.Int("fmvn")
+		if err != nil {
+			return rec, err
+		}
+	}
+
+	return rec, nil
+}
+
+// parseOps parses the operations of a record.
+func parseOps(s string) (Ops, error) {
+	ops := make(Ops)
+
+	var opcode string
+	var operands []string
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+
+		var word string
+		switch s[0] {
+		case ';':
+			if opcode == "" {
+				return nil, errors.New("Operation without an opcode")
+			}
+			ops[opcode] = operands
+			opcode, operands = "", nil
+			s = s[1:]
+			continue
+
+		case '"':
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return nil, errors.New("Unterminated string in EPD operations: '" + s + "'")
+			}
+			word, s = s[1:end+1], s[end+2:]
+
+		default:
+			end := strings.IndexAny(s, " \t;")
+			if end < 0 {
+				end = len(s)
+			}
+			word, s = s[:end], s[end:]
+		}
+
+		if opcode == "" {
+			if !isOpcode(word) {
+				return nil, errors.New("Bad opcode '" + word + "'")
+			}
+			opcode = word
+			operands = []string{}
+		} else {
+			operands = append(operands, word)
+		}
+	}
+
+	if opcode != "" {
+		return nil, errors.New("Operation '" + opcode + "' is missing its semicolon")
+	}
+
+	return ops, nil
+}
+
+// isOpcode returns whether s is a valid opcode: a letter
+// followed by up to 14 letters, digits and underscores.
+func isOpcode(s string) bool {
+	if len(s) == 0 || len(s) > 15 {
+		return false
+	}
+	for i, ch := range s {
+		letter := 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z'
+		if !letter && (i == 0 || !(ch == '_' || '0' <= ch && ch <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the record as a line of EPD, without
+// a newline. The operations are in a standard order:
+// see Ops.Opcodes. EPD has no way to escape a double quote
+// in an operand, so String writes it as a single quote;
+// Write returns an error for such records instead.
+func (r Record) String() string {
+	fen := strings.Fields(r.Position.FEN())
+
+	var sb strings.Builder
+	sb.WriteString(strings.Join(fen[:4], " "))
+
+	for _, opcode := range r.Ops.Opcodes() {
+		sb.WriteString(" " + opcode)
+		for _, operand := range r.Ops[opcode] {
+			sb.WriteByte(' ')
+			if quotedOpcodes[opcode] || operand == "" || strings.ContainsAny(operand, " \t;\"") {
+				sb.WriteString(`"` + strings.Replace(operand, `"`, "'", -1) + `"`)
+			} else {
+				sb.WriteString(operand)
+			}
+		}
+		sb.WriteByte(';')
+	}
+
+	return sb.String()
+}
+
+// Check returns an error if the record can't be written as
+// EPD: if an opcode is malformed, or an operand contains a
+// double quote or a line break.
+func (r Record) Check() error {
+	for _, opcode := range r.Ops.Opcodes() {
+		if !isOpcode(opcode) {
+			return errors.New("Bad opcode '" + opcode + "'")
+		}
+		for _, operand := range r.Ops[opcode] {
+			if strings.ContainsAny(operand, "\"\r\n") {
+				return fmt.Errorf("Operand %q of '%s' can't be written in EPD", operand, opcode)
+			}
+		}
+	}
+	return nil
+}
+
+// Game returns a game that starts from the record's position
+// and has no moves, so that it can be analyzed like any other.
+func (r Record) Game() chess.Game {
+	return chess.Game{
+		Tags: map[string]string{
+			"SetUp": "1",
+			"FEN":   r.Position.FEN(),
+		},
+		Board: r.Position.Board,
+	}
+}
+
+// Read reads all the records from input, one per line.
+// Blank lines and lines starting with '#' are skipped.
+func Read(input io.Reader) ([]Record, error) {
+	var records []Record
+
+	scanner := bufio.NewScanner(input)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		rec, err := Parse(text)
+		if err != nil {
+			return records, fmt.Errorf("Line %d: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+// Write writes the records to w, one per line. It is an
+// error if a record can't be written as EPD and read back
+// the same: see Record.Check.
+func Write(w io.Writer, records []Record) error {
+	bw := bufio.NewWriter(w)
+	for _, rec := range records {
+		if err := rec.Check(); err != nil {
+			return err
+		}
+		_, err := bw.WriteString(rec.String() + "\n")
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Ops maps the opcodes of a record's operations to their
+// operands. Operands are strings as written, without quotes;
+// the methods convert the standard ones to their types.
+type Ops map[string][]string
+
+// BestMoves returns the best moves (bm), in SAN.
+func (o Ops) BestMoves() []string {
+	return o["bm"]
+}
+
+// AvoidMoves returns the moves to avoid (am), in SAN.
+func (o Ops) AvoidMoves() []string {
+	return o["am"]
+}
+
+// ID returns the identifier of the record (id).
+func (o Ops) ID() string {
+	return o.String("id")
+}
+
+// CentipawnEval returns the evaluation (ce) in centipawns,
+// from the point of view of the player to move.
+func (o Ops) CentipawnEval() (int, error) {
+	return o.Int("ce")
+}
+
+// DirectMate returns the number of moves to mate (dm).
+func (o Ops) DirectMate() (int, error) {
+	return o.Int("dm")
+}
+
+// Comment returns comment n (c0 through c9).
+func (o Ops) Comment(n int) string {
+	return o.String("c" + strconv.Itoa(n))
+}
+
+// String returns the first operand of the opcode, or
+// "" if the record doesn't have the opcode.
+func (o Ops) String(opcode string) string {
+	operands := o[opcode]
+	if len(operands) == 0 {
+		return ""
+	}
+	return operands[0]
+}
+
+// Int returns the single operand of the opcode as an integer.
+// It is an error if the record doesn't have the opcode.
+func (o Ops) Int(opcode string) (int, error) {
+	operands, ok := o[opcode]
+	if !ok {
+		return 0, errors.New("No '" + opcode + "' operation")
+	}
+	if len(operands) != 1 {
+		return 0, fmt.Errorf("Operation '%s' should have 1 operand; has %d", opcode, len(operands))
+	}
+	n, err := strconv.Atoi(operands[0])
+	if err != nil {
+		return 0, fmt.Errorf("Operation '%s' has a bad integer '%s'", opcode, operands[0])
+	}
+	return n, nil
+}
+
+// Set sets the operands of the opcode, replacing any
+// it had. The ops must not be nil.
+func (o Ops) Set(opcode string, operands ...string) {
+	o[opcode] = operands
+}
+
+// Opcodes returns the opcodes in the order they are written:
+// the common ones in a fixed order, then the rest sorted.
+func (o Ops) Opcodes() []string {
+	var opcodes, others []string
+	for _, opcode := range opcodeOrder {
+		if _, ok := o[opcode]; ok {
+			opcodes = append(opcodes, opcode)
+		}
+	}
+	for opcode := range o {
+		if opcodeRank(opcode) < 0 {
+			others = append(others, opcode)
+		}
+	}
+	sort.Strings(others)
+	return append(opcodes, others...)
+}
+
+// opcodeRank returns the index of opcode in opcodeOrder,
+// or -1 if it isn't there.
+func opcodeRank(opcode string) int {
+	for i, op := range opcodeOrder {
+		if op == opcode {
+			return i
+		}
+	}
+	return -1
+}
+
+// opcodeOrder is the order in which common opcodes are written.
+var opcodeOrder = []string{
+	"bm", "am", "pm", "pv", "dm", "ce", "acd", "acn", "acs",
+	"hmvc", "fmvn", "id", "c0", "c1", "c2", "c3", "c4", "c5", "c6", "c7", "c8", "c9",
+}
+
+// quotedOpcodes are the opcodes whose operands are
+// strings, which are always written in quotes.
+var quotedOpcodes = map[string]bool{
+	"id": true, "c0": true, "c1": true, "c2": true, "c3": true, "c4": true,
+	"c5": true, "c6": true, "c7": true, "c8": true, "c9": true,
+}
@@ -0,0 +1,103 @@
+package epd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	rec, err := Parse(`r1b1k2r/ppppnppp/2n2q2/2b5/3NP3/2P1B3/PP3PPP/RN1QKB1R w KQkq - bm Nxc6 Nb5; ce 35; id "test; one"; hmvc 4; fmvn 9; noop;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rec.Ops.BestMoves(); !reflect.DeepEqual(got, []string{"Nxc6", "Nb5"}) {
+		t.Errorf("Got best moves %v, want [Nxc6 Nb5]", got)
+	}
+	if ce, err := rec.Ops.CentipawnEval(); err != nil || ce != 35 {
+		t.Errorf("Got evaluation %d (%v), want 35", ce, err)
+	}
+	if id := rec.Ops.ID(); id != "test; one" {
+		t.Errorf("Got id %q, want %q", id, "test; one")
+	}
+	if operands, ok := rec.Ops["noop"]; !ok || len(operands) != 0 {
+		t.Errorf("Got noop operands %v (%v), want none", operands, ok)
+	}
+	if rec.Position.HalfMoves != 4 || rec.Position.MoveNumber != 9 {
+		t.Errorf("Got halfmove clock %d and move number %d, want 4 and 9", rec.Position.HalfMoves, rec.Position.MoveNumber)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"8/8/8/8/8/8/8/8 w -",
+		"4k3/8/8/8/8/8/8/4K3 w - - bm Kd2",
+		"4k3/8/8/8/8/8/8/4K3 w - - id \"test;",
+		"4k3/8/8/8/8/8/8/4K3 w - - ; bm Kd2;",
+		"4k3/8/8/8/8/8/8/4K3 w - - 1bm Kd2;",
+		"4k3/8/8/8/8/8/8/4K3 w - - hmvc x;",
+	} {
+		if _, err := Parse(line); err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	input := `# A test suite
+
+4k3/8/8/8/8/8/8/R3K3 w Q - bm Ra8+; dm 1; id "mate.1"; c0 "Back rank";
+r3k2r/8/8/3pP3/8/8/8/R3K2R w KQkq d6 c1 ""; pv exd6 O-O; ce -12; custom a b;
+`
+	want := []string{
+		`4k3/8/8/8/8/8/8/R3K3 w Q - bm Ra8+; dm 1; id "mate.1"; c0 "Back rank";`,
+		`r3k2r/8/8/3pP3/8/8/8/R3K2R w KQkq d6 pv exd6 O-O; ce -12; c1 ""; custom a b;`,
+	}
+
+	records, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, records); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("Got:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	again, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, records) {
+		t.Errorf("Records changed in round trip:\ngot  %+v\nwant %+v", again, records)
+	}
+}
+
+func TestWriteUnrepresentable(t *testing.T) {
+	rec, err := Parse(`4k3/8/8/8/8/8/8/4K3 w - - id "ok";`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, operand := range []string{`say "hi"`, "two\nlines"} {
+		rec.Ops.Set("c0", operand)
+		if err := rec.Check(); err == nil {
+			t.Errorf("%q: expected an error from Check", operand)
+		}
+		if err := Write(new(bytes.Buffer), []Record{rec}); err == nil {
+			t.Errorf("%q: expected an error from Write", operand)
+		}
+	}
+
+	// String does its best, since it can't fail
+	rec.Ops.Set("c0", `say "hi"`)
+	if got, want := rec.String(), `4k3/8/8/8/8/8/8/4K3 w - - id "ok"; c0 "say 'hi'";`; got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+}
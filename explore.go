@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/mholt/chessml/chess"
+	"github.com/mholt/chessml/explorer"
+	"github.com/mholt/chessml/pgn"
+)
+
+// explore builds an opening explorer index from PGN files, or
+// queries one, depending on whether args start with "build"
+// or "query".
+func explore(args []string) {
+	if len(args) == 0 {
+		log.Fatal("Usage: explore build [flags] files... | explore query [flags] [moves]")
+	}
+	switch args[0] {
+	case "build":
+		buildIndex(args[1:])
+	case "query":
+		queryIndex(args[1:])
+	default:
+		log.Fatalf("Unknown explore command '%s'; must be build or query", args[0])
+	}
+}
+
+// buildIndex indexes the games in the PGN files named in
+// args, after any flags, and saves the index.
+func buildIndex(args []string) {
+	fs := flag.NewFlagSet("explore build", flag.ExitOnError)
+	out := fs.String("o", "explorer.idx", "write the index to this `file`")
+	maxPly := fs.Int("maxply", 30, "index this many `plies` of each game, or 0 for all")
+	filterExpr := fs.String("filter", "", "only use games whose tags match this `expression`")
+	fs.Parse(args)
+
+	var filter *pgn.Filter
+	if *filterExpr != "" {
+		var err error
+		filter, err = pgn.ParseFilter(*filterExpr)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	index := explorer.NewIndex(*maxPly)
+
+	for _, path := range fs.Args() {
+		f, err := pgn.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		opts := pgn.ParallelOptions{Lenient: true, Filter: filter}
+		gameErrs, err := pgn.ParseParallel(f, opts, func(game chess.Game) error {
+			if err := index.Add(game); err != nil {
+				log.Printf("%s: %v; skipping the rest of that game", path, err)
+			}
+			return nil
+		})
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, gameErr := range gameErrs {
+			log.Printf("%s: %v; skipping that game", path, gameErr)
+		}
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = index.Save(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Indexed %d games to %s\n", index.Games, *out)
+}
+
+// queryIndex prints the moves played from a position, given
+// by the -fen flag or by the moves in args after any flags.
+func queryIndex(args []string) {
+	fs := flag.NewFlagSet("explore query", flag.ExitOnError)
+	in := fs.String("i", "explorer.idx", "read the index from this `file`")
+	fen := fs.String("fen", "", "query the position in this `FEN` instead of moves")
+	fs.Parse(args)
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	index, err := explorer.Load(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var results []explorer.MoveResult
+	if *fen != "" {
+		results, err = index.QueryFEN(*fen)
+	} else {
+		results, err = index.QueryMoves(strings.Join(fs.Args(), " "))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(results) == 0 {
+		fmt.Println("No games reached this position")
+		return
+	}
+
+	fmt.Printf("%-6s %8s %7s %7s %7s %8s\n", "Move", "Games", "White", "Draw", "Black", "Avg Elo")
+	for _, r := range results {
+		fmt.Printf("%-6s %8d %6.1f%% %6.1f%% %6.1f%% %8.0f\n", r.Move.UCI(), r.Games,
+			r.WhitePct(), r.DrawPct(), r.BlackPct(), r.AverageElo)
+	}
+}
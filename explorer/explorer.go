@@ -0,0 +1,235 @@
+// Package explorer is an opening explorer: it indexes the moves
+// played from each position of a corpus of games, so that the
+// moves and how they scored can be looked up for any position.
+package explorer
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mholt/chessml/chess"
+	"github.com/mholt/chessml/pgn"
+	"github.com/mholt/chessml/polyglot"
+)
+
+// NewIndex returns an empty index which records the first
+// maxPly moves of each game added, or all of them if maxPly
+// is 0.
+func NewIndex(maxPly int) *Index {
+	return &Index{
+		MaxPly:    maxPly,
+		positions: make(map[uint64][]moveStats),
+	}
+}
+
+// An Index holds the moves played from each position of the
+// games added to it, keyed by the position's Polyglot key.
+type Index struct {
+	MaxPly int // number of moves of each game indexed, or 0 for all
+	Games  int // number of games added
+
+	positions map[uint64][]moveStats
+}
+
+// moveStats counts the games in which a move was played.
+type moveStats struct {
+	Move                        uint16 // encoded as in Polyglot books
+	Games                       int
+	WhiteWins, Draws, BlackWins int
+	EloSum                      int64 // sum of the ratings of the players making the move
+	EloGames                    int   // number of games where the player was rated
+}
+
+// Add adds the moves of a game to the index. An error means
+// the game could not be replayed; the moves before the bad
+// one are still added.
+func (ix *Index) Add(game chess.Game) error {
+	result, _ := game.Result()
+	white, _ := game.WhiteElo()
+	black, _ := game.BlackElo()
+
+	ix.Games++
+
+	err := game.Replay(func(pos chess.Position, m chess.PlayedMove) error {
+		if ix.MaxPly > 0 && m.Ply >= ix.MaxPly {
+			return errMaxPly
+		}
+
+		key := polyglot.Key(pos)
+		move := polyglot.EncodeMove(m)
+
+		moves := ix.positions[key]
+		i := 0
+		for i < len(moves) && moves[i].Move != move {
+			i++
+		}
+		if i == len(moves) {
+			moves = append(moves, moveStats{Move: move})
+			ix.positions[key] = moves
+		}
+		stats := &moves[i]
+
+		stats.Games++
+		switch result {
+		case chess.ResultWhiteWin:
+			stats.WhiteWins++
+		case chess.ResultBlackWin:
+			stats.BlackWins++
+		case chess.ResultDraw:
+			stats.Draws++
+		}
+
+		elo := white
+		if pos.ToMove == chess.BlackTeam {
+			elo = black
+		}
+		if elo > 0 {
+			stats.EloSum += int64(elo)
+			stats.EloGames++
+		}
+
+		return nil
+	})
+	if err == errMaxPly {
+		return nil
+	}
+	return err
+}
+
+// Query returns the moves played from the position, from the
+// most often played to the least.
+func (ix *Index) Query(pos chess.Position) ([]MoveResult, error) {
+	var results []MoveResult
+
+	for _, stats := range ix.positions[polyglot.Key(pos)] {
+		m, err := polyglot.DecodeMove(pos, stats.Move)
+		if err != nil {
+			return nil, err
+		}
+
+		r := MoveResult{
+			Move:      m,
+			Games:     stats.Games,
+			WhiteWins: stats.WhiteWins,
+			Draws:     stats.Draws,
+			BlackWins: stats.BlackWins,
+		}
+		if stats.EloGames > 0 {
+			r.AverageElo = float64(stats.EloSum) / float64(stats.EloGames)
+		}
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Games != results[j].Games {
+			return results[i].Games > results[j].Games
+		}
+		return results[i].Move.UCI() < results[j].Move.UCI()
+	})
+
+	return results, nil
+}
+
+// QueryFEN is like Query, but for a position given in FEN.
+func (ix *Index) QueryFEN(fen string) ([]MoveResult, error) {
+	pos, err := chess.ParseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	return ix.Query(pos)
+}
+
+// QueryMoves is like Query, but for the position reached by
+// playing moves from the initial position. The moves are PGN
+// movetext, like "1. e4 c5 2. Nf3" or just "e4 c5 Nf3". If
+// there are no moves, the initial position is queried.
+func (ix *Index) QueryMoves(moves string) ([]MoveResult, error) {
+	if strings.TrimSpace(moves) == "" {
+		return ix.QueryFEN(chess.StartFEN)
+	}
+
+	games, err := pgn.Parse(strings.NewReader(moves))
+	if err != nil {
+		return nil, err
+	}
+	if len(games) != 1 {
+		return nil, errors.New("Expected the moves of one game")
+	}
+
+	game := games[0]
+	err = game.Execute(-1)
+	if err != nil {
+		return nil, err
+	}
+	return ix.Query(game.Position())
+}
+
+// A MoveResult is how often a move was played from a
+// position and how the games went.
+type MoveResult struct {
+	Move                        chess.PlayedMove
+	Games                       int
+	WhiteWins, Draws, BlackWins int
+	AverageElo                  float64 // of the players making the move, if rated
+}
+
+// WhitePct, DrawPct and BlackPct return the percentage of the
+// games that White won, that were drawn, and that Black won.
+// Games without a result count toward none of them.
+func (r MoveResult) WhitePct() float64 { return pct(r.WhiteWins, r.Games) }
+func (r MoveResult) DrawPct() float64  { return pct(r.Draws, r.Games) }
+func (r MoveResult) BlackPct() float64 { return pct(r.BlackWins, r.Games) }
+
+func pct(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// Save writes the index to w, to be read with Load.
+func (ix *Index) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(indexData{
+		Version:   indexVersion,
+		MaxPly:    ix.MaxPly,
+		Games:     ix.Games,
+		Positions: ix.positions,
+	})
+}
+
+// Load reads an index written by Save.
+func Load(r io.Reader) (*Index, error) {
+	var data indexData
+	err := gob.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Version != indexVersion {
+		return nil, errors.New("Index was saved in an unknown format")
+	}
+
+	ix := NewIndex(data.MaxPly)
+	ix.Games = data.Games
+	if data.Positions != nil {
+		ix.positions = data.Positions
+	}
+	return ix, nil
+}
+
+// indexData is the persisted form of an index.
+type indexData struct {
+	Version   int
+	MaxPly    int
+	Games     int
+	Positions map[uint64][]moveStats
+}
+
+// indexVersion is the version of the format written by
+// Save, which changes if indexData changes.
+const indexVersion = 1
+
+// errMaxPly stops replaying a game at the index's maximum ply.
+var errMaxPly = errors.New("Reached the maximum ply")
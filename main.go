@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/mholt/chessml/analysis"
 	"github.com/mholt/chessml/arff"
 	"github.com/mholt/chessml/chess"
 	"github.com/mholt/chessml/pgn"
@@ -16,21 +18,106 @@ import (
 const numGames = 2000
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(validate(os.Args[2:]))
+		case "book":
+			buildBook(os.Args[2:])
+			return
+		case "explore":
+			explore(os.Args[2:])
+			return
+		}
+	}
+
+	filterExpr := flag.String("filter", "", "only use games whose tags match this `expression`, like \"MinElo >= 2200\"")
+	dedupe := flag.Bool("dedupe", false, "skip games whose moves duplicate an earlier game's")
+	dedupeTags := flag.Bool("dedupe-tags", false, "with -dedupe, only count games as duplicates if their players and dates match too")
+	byPhase := flag.Bool("by-phase", false, "snapshot each game in its opening, middlegame and endgame instead of 75% of the way through")
+	flag.Parse()
+
+	var filter *pgn.Filter
+	if *filterExpr != "" {
+		var err error
+		filter, err = pgn.ParseFilter(*filterExpr)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var deduper *pgn.Deduper
+	if *dedupe {
+		deduper = pgn.NewDeduper()
+		deduper.MatchPlayers = *dedupeTags
+		deduper.MatchDate = *dedupeTags
+	}
+
 	fmt.Printf("Loading %d random games\n", numGames)
-	games := loadRandomGames("pgnfiles/", numGames)
+	games := loadRandomGames("pgnfiles/", numGames, filter, deduper)
+
+	if deduper != nil {
+		printDuplicates(deduper.Report())
+	}
 
 	fmt.Print("\nSnapshotting each game and writing ARFF file...")
-	arff.GenerateARFF(games, []float64{0.75}, "data/chess75.arff")
+	if *byPhase {
+		phases := []analysis.GamePhase{analysis.Opening, analysis.Middlegame, analysis.Endgame}
+		arff.GenerateARFFByPhase(games, phases, "data/chess-phases.arff")
+	} else {
+		arff.GenerateARFF(games, []float64{0.75}, "data/chess75.arff")
+	}
 	fmt.Println(" done!")
 }
 
+// validate parses and replays every game in the PGN files
+// named by paths, printing any problems found. It returns
+// the exit status: 0 if all games are valid, 1 otherwise.
+func validate(paths []string) int {
+	status := 0
+
+	for _, path := range paths {
+		f, err := pgn.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		reports, count, err := pgn.Validate(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, report := range reports {
+			if report.Err != nil {
+				fmt.Printf("%s: %v\n", path, report.Err)
+				continue
+			}
+			for _, problem := range report.Problems {
+				fmt.Printf("%s: Game %d (%s - %s): %s\n", path, report.Index,
+					report.Tags["White"], report.Tags["Black"], problem)
+			}
+		}
+
+		fmt.Printf("%s: %d games, %d with problems\n", path, count, len(reports))
+		if len(reports) > 0 {
+			status = 1
+		}
+	}
+
+	return status
+}
+
 // loadRandomGames will load at most n games from
-// any .pgn files in the directory dir. It will
+// any PGN files in the directory dir, which may
+// be compressed (see pgn.IsPGNFile). It will
 // traverse the directory to child directories
 // searching as well. The games are randomly
-// chosen. This function is O(n) because it uses
+// chosen from those matching filter, if it isn't
+// nil, and duplicates are left out if deduper isn't
+// nil. This function is O(n) because it uses
 // reservoir sampling.
-func loadRandomGames(dir string, n int) []chess.Game {
+func loadRandomGames(dir string, n int, filter *pgn.Filter, deduper *pgn.Deduper) []chess.Game {
 	var games = make([]chess.Game, 0, n)
 	var k int
 
@@ -40,23 +127,26 @@ func loadRandomGames(dir string, n int) []chess.Game {
 		if err != nil {
 			log.Fatal(err)
 		}
-		if filepath.Ext(path) != ".pgn" {
+		if info.IsDir() || !pgn.IsPGNFile(path) {
 			return nil
 		}
 
 		fmt.Println(path)
 
-		f, err := os.Open(path)
+		f, err := pgn.Open(path)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		fgames, err := pgn.Parse(f)
-		if err != nil {
-			log.Fatal(err)
-		}
+		defer f.Close()
+
+		// Skip games that can't be parsed rather than losing the rest of the file
+		opts := pgn.ParallelOptions{Lenient: true, Filter: filter}
+		gameErrs, err := pgn.ParseParallel(f, opts, func(game chess.Game) error {
+			if deduper != nil && !deduper.Add(game, path) {
+				return nil
+			}
 
-		for _, game := range fgames {
 			k++
 
 			if k <= n {
@@ -70,6 +160,13 @@ func loadRandomGames(dir string, n int) []chess.Game {
 				}
 			}
 
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, gameErr := range gameErrs {
+			log.Printf("%s: %v; skipping that game", path, gameErr)
 		}
 
 		return nil
@@ -77,3 +174,18 @@ func loadRandomGames(dir string, n int) []chess.Game {
 
 	return games
 }
+
+// printDuplicates prints how many duplicate games were
+// found in each source that had any.
+func printDuplicates(report pgn.DedupeReport) {
+	fmt.Printf("%d games, %d unique\n", report.Games, report.Unique)
+	for _, stats := range report.Sources {
+		if stats.Duplicates == 0 {
+			continue
+		}
+		fmt.Printf("%s: %d of %d games are duplicates\n", stats.Source, stats.Duplicates, stats.Games)
+		for source, n := range stats.DuplicatesOf {
+			fmt.Printf("    %d first seen in %s\n", n, source)
+		}
+	}
+}
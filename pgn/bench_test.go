@@ -0,0 +1,136 @@
+package pgn
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// benchGames is the number of games in the benchmark corpus.
+const benchGames = 1000
+
+func BenchmarkReader(b *testing.B) {
+	corpus := syntheticCorpus(benchGames)
+	b.SetBytes(int64(len(corpus)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		reader := NewReader(bytes.NewReader(corpus))
+		count := 0
+		for {
+			_, err := reader.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+			count++
+		}
+		if count != benchGames {
+			b.Fatalf("Parsed %d games, want %d", count, benchGames)
+		}
+	}
+}
+
+func BenchmarkParseParallel(b *testing.B) {
+	corpus := syntheticCorpus(benchGames)
+	opts := ParallelOptions{ChunkSize: 64 << 10} // small enough to keep the workers busy
+	b.SetBytes(int64(len(corpus)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		count := 0
+		_, err := ParseParallel(bytes.NewReader(corpus), opts, func(chess.Game) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if count != benchGames {
+			b.Fatalf("Parsed %d games, want %d", count, benchGames)
+		}
+	}
+}
+
+// syntheticCorpus generates n games of PGN text resembling an
+// online-game export: a full tag section, and movetext from
+// real games with clock comments after every move. It's held
+// in memory so that disk speed doesn't matter.
+func syntheticCorpus(n int) []byte {
+	var buf bytes.Buffer
+	rnd := rand.New(rand.NewSource(1))
+	results := []string{"1-0", "0-1", "1/2-1/2"}
+
+	for i := 0; i < n; i++ {
+		moves := strings.Fields(benchMovetexts[i%len(benchMovetexts)])
+		result := results[rnd.Intn(len(results))]
+
+		fmt.Fprintf(&buf, "[Event \"Rated Blitz game\"]\n")
+		fmt.Fprintf(&buf, "[Site \"https://example.org/%08x\"]\n", rnd.Uint32())
+		fmt.Fprintf(&buf, "[Date \"%d.%02d.%02d\"]\n", 2010+rnd.Intn(10), 1+rnd.Intn(12), 1+rnd.Intn(28))
+		fmt.Fprintf(&buf, "[Round \"-\"]\n")
+		fmt.Fprintf(&buf, "[White \"player%d\"]\n", rnd.Intn(100000))
+		fmt.Fprintf(&buf, "[Black \"player%d\"]\n", rnd.Intn(100000))
+		fmt.Fprintf(&buf, "[Result \"%s\"]\n", result)
+		fmt.Fprintf(&buf, "[WhiteElo \"%d\"]\n", 1200+rnd.Intn(1400))
+		fmt.Fprintf(&buf, "[BlackElo \"%d\"]\n", 1200+rnd.Intn(1400))
+		fmt.Fprintf(&buf, "[TimeControl \"300+3\"]\n")
+		fmt.Fprintf(&buf, "[ECO \"C%02d\"]\n", rnd.Intn(100))
+		fmt.Fprintf(&buf, "[Termination \"Normal\"]\n\n")
+
+		clock := 300
+		line := 0
+		for j, mv := range moves {
+			var text string
+			if j%2 == 0 {
+				text = strconv.Itoa(j/2+1) + ". " + mv
+			} else {
+				text = strconv.Itoa(j/2+1) + "... " + mv
+			}
+			clock -= rnd.Intn(10)
+			if clock < 1 {
+				clock = 1
+			}
+			text += fmt.Sprintf(" { [%%clk 0:%02d:%02d] } ", clock/60, clock%60)
+
+			if line+len(text) > 79 {
+				buf.WriteByte('\n')
+				line = 0
+			}
+			buf.WriteString(text)
+			line += len(text)
+		}
+		buf.WriteString(result + "\n\n")
+	}
+
+	return buf.Bytes()
+}
+
+// benchMovetexts are moves of real games, for the synthetic corpus.
+var benchMovetexts = []string{
+	`e4 e5 Nf3 Nc6 Bb5 a6 Ba4 Nf6 O-O Be7 Re1 b5 Bb3 d6 c3 O-O h3 Nb8 d4 Nbd7
+	 c4 c6 cxb5 axb5 Nc3 Bb7 Bg5 b4 Nb1 h6 Bh4 c5 dxe5 Nxe4 Bxe7 Qxe7 exd6 Qf6
+	 Nbd2 Nxd6 Nc4 Nxc4 Bxc4 Nb6 Ne5 Rae8 Bxf7+ Rxf7 Nxf7 Rxe1+ Qxe1 Kxf7 Qe3
+	 Qg5 Qxg5 hxg5 b3 Ke6 a3 Kd6 axb4 cxb4 Ra5 Nd5 f3 Bc8 Kf2 Bf5 Ra7 g6 Ra6+
+	 Kc5 Ke1 Nf4 g3 Nxh3 Kd2 Kb5 Rd6 Kc5 Ra6 Nf2 g4 Bd3 Re6`,
+	`d4 Nf6 c4 e6 Nc3 Bb4 e3 O-O Bd3 d5 Nf3 c5 O-O Nc6 a3 Bxc3 bxc3 dxc4 Bxc4
+	 Qc7 Bd3 e5 Qc2 Re8 Nxe5 Nxe5 dxe5 Qxe5 f3 Bd7 a4 Qc7 e4 c4 Be2 Bc6 Be3
+	 Nd7 Rad1 Nc5 Bxc5 Qxc5+ Kh1 Rad8 Rxd8 Rxd8 Qb2 b6 Rd1 Rxd1+ Bxd1 Qe3 Qc2
+	 Qe1+ Qxe1`,
+	`e4 c5 Nf3 d6 d4 cxd4 Nxd4 Nf6 Nc3 a6 Be3 e5 Nb3 Be6 f3 Be7 Qd2 O-O O-O-O
+	 Nbd7 g4 b5 g5 b4 Ne2 Ne8 f4 a5 f5 a4 Nbd4 exd4 Nxd4 b3 Kb1 bxc2+ Nxc2 Bb3
+	 axb3 axb3 Na3 Ne5 h4 Ra4 Bh3 Qa5 Qc3 Qxc3 bxc3 Rxa3 Ka1 Rxe3`,
+	`c4 e5 Nc3 Nf6 Nf3 Nc6 g3 d5 cxd5 Nxd5 Bg2 Nb6 O-O Be7 d3 O-O a3 Be6 b4 f6
+	 Bb2 a5 b5 Nd4 Nd2 c6 bxc6 bxc6 e3 Nf5 Qc2 Rc8 Rfc1 Qd7 Nde4 Nd5 Nxd5 Bxd5
+	 Nc5 Bxc5 Qxc5 Bxg2 Kxg2 Nd6 Qxa5 Qe6 Qd2 Rb8 Bc3 Rb3 Rcb1 Rxb1 Rxb1 Qd5+
+	 Kg1 Nf5 Rb8 Rxb8 Qb4 Qxd3 Qxb8+ Kf7 Qb7+ Kg6 Qxc6 Qd1+ Kg2 Qd5+ Qxd5`,
+}
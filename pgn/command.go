@@ -0,0 +1,162 @@
+package pgn
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// parseCommands extracts the commands embedded in a comment, like
+// [%clk 0:03:12] or [%eval -1.24], and stores their values on the
+// move m. Commands this parser does not know are left alone. It
+// returns the comment with the known commands removed.
+func parseCommands(comment string, m *chess.Move) (string, error) {
+	var rest string
+
+	for {
+		start := strings.Index(comment, "[%")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(comment[start:], ']')
+		if end < 0 {
+			return comment, errors.New("Unterminated command in comment")
+		}
+		end += start
+
+		name, arg := comment[start+2:end], ""
+		if i := strings.IndexAny(name, " \t\r\n"); i > -1 {
+			name, arg = name[:i], strings.TrimSpace(name[i+1:])
+		}
+
+		switch name {
+		case "clk":
+			d, err := parseClock(arg)
+			if err != nil {
+				return comment, err
+			}
+			m.Clock = &d
+		case "emt":
+			d, err := parseClock(arg)
+			if err != nil {
+				return comment, err
+			}
+			m.Elapsed = &d
+		case "eval":
+			ev, err := parseEval(arg)
+			if err != nil {
+				return comment, err
+			}
+			m.Eval = &ev
+		default:
+			start = end + 1 // keep unknown commands in the text
+		}
+
+		rest = appendText(rest, comment[:start])
+		comment = comment[end+1:]
+	}
+
+	rest = appendText(rest, comment)
+	if rest == "" {
+		return "", nil
+	}
+	return strings.Join(strings.Fields(rest), " "), nil
+}
+
+// appendText appends s to text if s is not just whitespace.
+// Comments are usually nothing but commands, so this avoids
+// building strings for them.
+func appendText(text, s string) string {
+	if strings.TrimSpace(s) == "" {
+		return text
+	}
+	return text + " " + s
+}
+
+// parseClock parses a clock value of the form H:MM:SS,
+// where the seconds may have a fractional part. The hours
+// and minutes may be omitted. Values that are negative,
+// not finite, or too long for a time.Duration are errors.
+func parseClock(s string) (time.Duration, error) {
+	bad := func() (time.Duration, error) {
+		return 0, errors.New("Bad clock value '" + s + "'")
+	}
+
+	// Work from the seconds on the right to the hours on the left
+	rest := s
+	field := func() string {
+		i := strings.LastIndexByte(rest, ':')
+		f := rest[i+1:]
+		if i < 0 {
+			rest = ""
+		} else {
+			rest = rest[:i]
+		}
+		return f
+	}
+
+	secs, err := strconv.ParseFloat(field(), 64)
+	if err != nil || !(secs >= 0) || secs > maxClockSeconds {
+		return bad() // also catches NaN and infinity
+	}
+	d := time.Duration(secs * float64(time.Second))
+
+	for _, unit := range []time.Duration{time.Minute, time.Hour} {
+		if rest == "" {
+			break
+		}
+		n, err := strconv.Atoi(field())
+		if err != nil || n < 0 || float64(n)*unit.Seconds() > maxClockSeconds {
+			return bad()
+		}
+		d += time.Duration(n) * unit
+	}
+
+	if rest != "" {
+		return bad()
+	}
+
+	return d, nil
+}
+
+// maxClockSeconds is more than any real clock value, and small
+// enough that a sum of hours, minutes and seconds below it can't
+// overflow a time.Duration.
+const maxClockSeconds = 1e9
+
+// parseEval parses an evaluation, which is either a score in
+// pawns like -1.24 or a mate like #3 or #-3. Anything after a
+// comma (sometimes the search depth) is ignored.
+func parseEval(s string) (chess.Eval, error) {
+	var ev chess.Eval
+
+	if i := strings.Index(s, ","); i > -1 {
+		s = s[:i]
+	}
+
+	if strings.HasPrefix(s, "#") {
+		n, err := strconv.Atoi(s[1:])
+		if err != nil {
+			return ev, errors.New("Bad mate evaluation '" + s + "'")
+		}
+		ev.Mate = true
+		ev.Score = n
+		return ev, nil
+	}
+
+	pawns, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(pawns) || math.Abs(pawns) > math.MaxInt32/100 {
+		return ev, errors.New("Bad evaluation '" + s + "'")
+	}
+	if pawns < 0 {
+		ev.Score = int(pawns*100 - 0.5)
+	} else {
+		ev.Score = int(pawns*100 + 0.5)
+	}
+
+	return ev, nil
+}
@@ -0,0 +1,75 @@
+package pgn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseClock(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		want  time.Duration
+		ok    bool
+	}{
+		{"0:03:12", 3*time.Minute + 12*time.Second, true},
+		{"1:00:00", time.Hour, true},
+		{"2:05.5", 2*time.Minute + 5500*time.Millisecond, true},
+		{"7", 7 * time.Second, true},
+		{"0:00:00", 0, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"0:-1:00", 0, false},
+		{"NaN", 0, false},
+		{"0:00:NaN", 0, false},
+		{"Inf", 0, false},
+		{"+Inf", 0, false},
+		{"1e300", 0, false},
+		{"99999999999999:00:00", 0, false},
+		{"1:2:3:4", 0, false},
+		{"abc", 0, false},
+	} {
+		got, err := parseClock(test.input)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %v", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("%q: got %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseEval(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		score int
+		mate  bool
+		ok    bool
+	}{
+		{"0.17", 17, false, true},
+		{"-1.24", -124, false, true},
+		{"#3", 3, true, true},
+		{"#-2", -2, true, true},
+		{"0.5,20", 50, false, true},
+		{"NaN", 0, false, false},
+		{"Inf", 0, false, false},
+		{"#x", 0, false, false},
+	} {
+		ev, err := parseEval(test.input)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %+v", test.input, ev)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+		} else if ev.Score != test.score || ev.Mate != test.mate {
+			t.Errorf("%q: got %+v, want score %d, mate %v", test.input, ev, test.score, test.mate)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package pgn
+
+import (
+	"hash/fnv"
+	"strings"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// NewDeduper returns a Deduper that has seen no games.
+func NewDeduper() *Deduper {
+	return &Deduper{
+		seen:    make(map[dedupeKey]int),
+		sources: make(map[string]int),
+	}
+}
+
+// A Deduper finds games that are duplicates of games it has seen
+// before, as happens when a corpus is merged from several sources.
+// Games are the same if they have the same moves from the same
+// start position; tags are ignored unless MatchPlayers or MatchDate
+// is set, since different sources often spell them differently.
+// Check marks and annotations on moves are ignored as well.
+type Deduper struct {
+	MatchPlayers bool // whether the players' names must also match
+	MatchDate    bool // whether the dates must also match
+
+	seen    map[dedupeKey]int // source index of the first copy of each game
+	sources map[string]int    // index of each source in stats
+	stats   []SourceStats
+}
+
+// Add records a game read from the named source, which is
+// usually a file name, and returns whether it is the first
+// copy of the game seen.
+func (d *Deduper) Add(game chess.Game, source string) bool {
+	src, ok := d.sources[source]
+	if !ok {
+		src = len(d.stats)
+		d.sources[source] = src
+		d.stats = append(d.stats, SourceStats{Source: source})
+	}
+	stats := &d.stats[src]
+	stats.Games++
+
+	key := d.key(game)
+	first, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = src
+		return true
+	}
+
+	stats.Duplicates++
+	if stats.DuplicatesOf == nil {
+		stats.DuplicatesOf = make(map[string]int)
+	}
+	stats.DuplicatesOf[d.stats[first].Source]++
+	return false
+}
+
+// Report returns the number of games seen by the deduper and
+// how many of each source's games were duplicates, in the order
+// the sources were first seen.
+func (d *Deduper) Report() DedupeReport {
+	report := DedupeReport{Unique: len(d.seen)}
+	for _, stats := range d.stats {
+		report.Games += stats.Games
+		copied := stats
+		copied.DuplicatesOf = make(map[string]int, len(stats.DuplicatesOf))
+		for source, n := range stats.DuplicatesOf {
+			copied.DuplicatesOf[source] = n
+		}
+		report.Sources = append(report.Sources, copied)
+	}
+	return report
+}
+
+// Dedupe returns the games that are not duplicates of games
+// before them, keeping their order. See Deduper.
+func Dedupe(games []chess.Game) []chess.Game {
+	d := NewDeduper()
+	var unique []chess.Game
+	for _, game := range games {
+		if d.Add(game, "") {
+			unique = append(unique, game)
+		}
+	}
+	return unique
+}
+
+// key returns the key identifying a game's moves and,
+// depending on the options, its players and date.
+func (d *Deduper) key(game chess.Game) dedupeKey {
+	h := fnv.New128a()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	if start, err := game.StartPosition(); err == nil {
+		write(start.FEN())
+	}
+	if d.MatchPlayers {
+		write(strings.ToLower(strings.TrimSpace(game.Tags["White"])))
+		write(strings.ToLower(strings.TrimSpace(game.Tags["Black"])))
+	}
+	if d.MatchDate {
+		write(game.Tags["Date"])
+	}
+	for _, m := range game.Moves {
+		write(normalizeMove(m.Text))
+	}
+
+	var key dedupeKey
+	h.Sum(key[:0])
+	return key
+}
+
+// normalizeMove removes the parts of a move's text that
+// vary between sources without changing the move: check
+// and mate marks, suffix annotations, the '=' before a
+// promotion, and zeros written for castling.
+func normalizeMove(text string) string {
+	text = strings.TrimRight(text, "+#!?")
+	if strings.HasPrefix(text, "0-0") {
+		text = strings.Replace(text, "0", "O", -1)
+	}
+	return strings.Replace(text, "=", "", 1)
+}
+
+// dedupeKey is a hash identifying a game.
+type dedupeKey [16]byte
+
+// A DedupeReport summarizes the duplicates found by a Deduper.
+type DedupeReport struct {
+	Games   int // number of games seen
+	Unique  int // number of different games among them
+	Sources []SourceStats
+}
+
+// SourceStats counts the duplicate games in one source.
+type SourceStats struct {
+	Source     string
+	Games      int // number of games seen from the source
+	Duplicates int // how many of them were copies of earlier games
+
+	// DuplicatesOf counts the duplicates by the source of
+	// the first copy, which may be this source itself.
+	DuplicatesOf map[string]int
+}
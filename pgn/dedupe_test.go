@@ -0,0 +1,102 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupe(t *testing.T) {
+	input := `[Event "1"]
+[White "Carlsen, Magnus"]
+
+1. e4 e5 2. Nf3 Nf6 3. Bc4 Bc5 4. O-O O-O *
+
+[Event "2"]
+[White "Magnus Carlsen"]
+
+1. e4 e5 2. Nf3 Nf6 3. Bc4 Bc5 4. 0-0 0-0 *
+
+[Event "3"]
+[SetUp "1"]
+[FEN "8/4P3/8/8/8/8/k7/7K w - - 0 1"]
+
+1. e8=Q Kb2 *
+
+[Event "4"]
+[SetUp "1"]
+[FEN "8/4P3/8/8/8/8/k7/7K w - - 0 1"]
+
+1. e8Q! Kb2 *
+
+[Event "5"]
+[SetUp "1"]
+[FEN "8/4P3/8/8/8/8/k7/7K w - - 0 1"]
+
+1. e8=N Kb2 *
+
+[Event "6"]
+
+1. e4 e5 2. Nf3 Nf6 3. Bc4 Bc5 *
+
+[Event "7"]
+[SetUp "1"]
+[FEN "8/4P3/8/8/8/8/1k6/7K w - - 0 1"]
+
+1. e8=Q Kb2 *
+`
+	games, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+	for _, g := range Dedupe(games) {
+		events = append(events, g.Tags["Event"])
+	}
+	if got := strings.Join(events, ","); got != "1,3,5,6,7" {
+		t.Errorf("Got unique games %s, want 1,3,5,6,7", got)
+	}
+
+	// The players' names are spelled differently
+	d := NewDeduper()
+	d.MatchPlayers = true
+	for _, g := range games[:2] {
+		d.Add(g, "a.pgn")
+	}
+	if report := d.Report(); report.Unique != 2 {
+		t.Errorf("Matching players, got %d unique games, want 2", report.Unique)
+	}
+}
+
+func TestDeduperReport(t *testing.T) {
+	game := func(moves string) string {
+		return "[Event \"?\"]\n\n" + moves + " *\n\n"
+	}
+	a, err := Parse(strings.NewReader(game("1. e4 e5") + game("1. d4 d5") + game("1. e4 e5")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse(strings.NewReader(game("1. d4 d5") + game("1. c4 c5")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDeduper()
+	for _, g := range a {
+		d.Add(g, "a.pgn")
+	}
+	for _, g := range b {
+		d.Add(g, "b.pgn")
+	}
+
+	report := d.Report()
+	if report.Games != 5 || report.Unique != 3 || len(report.Sources) != 2 {
+		t.Fatalf("Got %+v, want 5 games, 3 unique, from 2 sources", report)
+	}
+	if s := report.Sources[0]; s.Games != 3 || s.Duplicates != 1 || s.DuplicatesOf["a.pgn"] != 1 {
+		t.Errorf("Got %+v for a.pgn, want 3 games with 1 duplicate of a.pgn", s)
+	}
+	if s := report.Sources[1]; s.Games != 2 || s.Duplicates != 1 || s.DuplicatesOf["a.pgn"] != 1 {
+		t.Errorf("Got %+v for b.pgn, want 2 games with 1 duplicate of a.pgn", s)
+	}
+}
@@ -0,0 +1,464 @@
+package pgn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// A Filter selects games by their tags. Filters are written as
+// expressions like these:
+//
+//	WhiteElo >= 2200 and BlackElo >= 2200
+//	MinElo > 2200 and Date >= 2015 and not Result = 1/2-1/2
+//	EstimatedTime >= 1500 and Termination != "time forfeit"
+//	Player ~ "(?i)carlsen" or (ECO >= B20 and ECO <= B99)
+//
+// A comparison is a tag name, an operator, and a value, which is
+// quoted if it contains spaces or operator characters. The
+// operators are = (or ==), !=, <, <=, >, >=, and ~ and !~ which
+// match a regular expression. Comparisons are combined with and,
+// or, not (or &&, ||, !) and parentheses; and binds tighter than
+// or. A tag name on its own means the game has that tag.
+//
+// Values are compared as numbers if the filter's value is a number,
+// and as dates if the tag name ends in "Date". Partial dates like
+// 2013.05.?? compare only as far as both dates are known, so
+// "Date = 2013" matches any game from 2013. Otherwise values are
+// compared as strings.
+//
+// A comparison is false if the game doesn't have the tag or its
+// value is unknown ("?", or a date whose year is unknown), except
+// that != and !~ are always the opposite of = and ~. There are
+// also some fields which aren't tags: MinElo and MaxElo are the
+// lower and higher of the two players' ratings, and Player compares
+// with both White and Black, matching if either one does. BaseTime,
+// Increment and EstimatedTime are the seconds of the time control;
+// see chess.TimeControl.
+type Filter struct {
+	expr filterExpr
+	text string
+}
+
+// ParseFilter parses a filter expression.
+func ParseFilter(text string) (*Filter, error) {
+	fp := &filterParser{text: text}
+	if err := fp.lex(); err != nil {
+		return nil, err
+	}
+
+	expr, err := fp.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if fp.pos < len(fp.tokens) {
+		return nil, fp.errorf("unexpected '%s'", fp.tokens[fp.pos].text)
+	}
+
+	return &Filter{expr: expr, text: text}, nil
+}
+
+// Match returns whether a game with the given tags
+// is selected by the filter.
+func (f *Filter) Match(tags map[string]string) bool {
+	return f.expr.match(tags)
+}
+
+func (f *Filter) String() string {
+	return f.text
+}
+
+// filterExpr is a node of a parsed filter expression.
+type filterExpr interface {
+	match(tags map[string]string) bool
+}
+
+type andExpr struct{ left, right filterExpr }
+
+func (e andExpr) match(tags map[string]string) bool {
+	return e.left.match(tags) && e.right.match(tags)
+}
+
+type orExpr struct{ left, right filterExpr }
+
+func (e orExpr) match(tags map[string]string) bool {
+	return e.left.match(tags) || e.right.match(tags)
+}
+
+type notExpr struct{ expr filterExpr }
+
+func (e notExpr) match(tags map[string]string) bool {
+	return !e.expr.match(tags)
+}
+
+// comparison compares a field of the game with a value.
+// The operators != and !~ are stored as = and ~ negated.
+type comparison struct {
+	field  string
+	op     string // "", "=", "<", "<=", ">", ">=", or "~"
+	negate bool
+	value  string
+	number float64
+	isNum  bool
+	date   chess.Date // the value, for fields that are dates
+	re     *regexp.Regexp
+}
+
+func (c comparison) match(tags map[string]string) bool {
+	matched := false
+	for _, v := range fieldValues(c.field, tags) {
+		if c.test(v) {
+			matched = true
+			break
+		}
+	}
+	return matched != c.negate
+}
+
+// test returns whether the value v of the field satisfies
+// the comparison.
+func (c comparison) test(v string) bool {
+	var order int
+
+	switch {
+	case c.op == "":
+		return true
+	case c.op == "~":
+		return c.re.MatchString(v)
+	case isDateField(c.field):
+		d, err := chess.ParseDate(v)
+		if err != nil {
+			return false
+		}
+		order = d.Compare(c.date)
+	case c.isNum:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case n < c.number:
+			order = -1
+		case n > c.number:
+			order = 1
+		}
+	default:
+		order = strings.Compare(v, c.value)
+	}
+
+	switch c.op {
+	case "=":
+		return order == 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
+
+// fieldValues returns the known values of a field for a game
+// with the given tags. Most fields are tags and have at most
+// one value; Player has two.
+func fieldValues(field string, tags map[string]string) []string {
+	known := func(tag string) (string, bool) {
+		v, ok := tags[tag]
+		return v, ok && v != "" && v != "?"
+	}
+	game := chess.Game{Tags: tags}
+
+	switch field {
+	case "Player":
+		var players []string
+		for _, tag := range []string{"White", "Black"} {
+			if v, ok := known(tag); ok {
+				players = append(players, v)
+			}
+		}
+		return players
+
+	case "MinElo", "MaxElo":
+		w, err1 := game.WhiteElo()
+		b, err2 := game.BlackElo()
+		if err1 != nil || err2 != nil || w == 0 || b == 0 {
+			return nil
+		}
+		if (field == "MinElo") == (w > b) {
+			w = b
+		}
+		return []string{strconv.Itoa(w)}
+
+	case "BaseTime", "Increment", "EstimatedTime":
+		tc, err := game.TimeControl()
+		if err != nil || len(tc.Stages) == 0 {
+			return nil
+		}
+		d := tc.Base()
+		if field == "Increment" {
+			d = tc.Increment()
+		} else if field == "EstimatedTime" {
+			d = tc.Estimated()
+		}
+		return []string{strconv.Itoa(int(d.Seconds()))}
+	}
+
+	if v, ok := known(field); ok {
+		if isDateField(field) {
+			// A date without a year, like ????.??.??, is unknown
+			if d, err := chess.ParseDate(v); err == nil && d.Year == 0 {
+				return nil
+			}
+		}
+		return []string{v}
+	}
+	return nil
+}
+
+// isDateField returns whether field holds a date,
+// like Date or EventDate.
+func isDateField(field string) bool {
+	return strings.HasSuffix(field, "Date")
+}
+
+// filterParser parses filter expressions by recursive descent.
+type filterParser struct {
+	text   string
+	tokens []filterToken
+	pos    int
+}
+
+// filterToken is a lexical unit of a filter expression.
+type filterToken struct {
+	text   string
+	quoted bool // whether the token was a quoted string
+	col    int  // position in the expression, starting at 1
+}
+
+// lex splits the filter text into tokens: parentheses,
+// operators, quoted strings, and words.
+func (fp *filterParser) lex() error {
+	s := fp.text
+
+	for i := 0; i < len(s); {
+		ch := s[i]
+		start := i
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+			continue
+
+		case ch == '(' || ch == ')':
+			i++
+
+		case strings.IndexByte(filterOpChars, ch) > -1:
+			for i < len(s) && strings.IndexByte(filterOpChars, s[i]) > -1 {
+				i++
+			}
+
+		case ch == '"':
+			var text []byte
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				text = append(text, s[i])
+			}
+			if i == len(s) {
+				return fmt.Errorf("Bad filter: unterminated string at char %d", start+1)
+			}
+			i++
+			fp.tokens = append(fp.tokens, filterToken{text: string(text), quoted: true, col: start + 1})
+			continue
+
+		default:
+			for i < len(s) && !isFilterBreak(s[i]) {
+				i++
+			}
+		}
+
+		fp.tokens = append(fp.tokens, filterToken{text: s[start:i], col: start + 1})
+	}
+
+	return nil
+}
+
+// parseOr parses expressions joined by "or".
+func (fp *filterParser) parseOr() (filterExpr, error) {
+	left, err := fp.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for fp.accept("or", "||") {
+		right, err := fp.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses expressions joined by "and".
+func (fp *filterParser) parseAnd() (filterExpr, error) {
+	left, err := fp.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for fp.accept("and", "&&") {
+		right, err := fp.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+// parseNot parses an expression that may be negated.
+func (fp *filterParser) parseNot() (filterExpr, error) {
+	if fp.accept("not", "!") {
+		expr, err := fp.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	return fp.parsePrimary()
+}
+
+// parsePrimary parses a parenthesized expression
+// or a comparison.
+func (fp *filterParser) parsePrimary() (filterExpr, error) {
+	tok, ok := fp.peek()
+	if !ok {
+		return nil, fp.errorf("expected a comparison")
+	}
+
+	if !tok.quoted && tok.text == "(" {
+		fp.pos++
+		expr, err := fp.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !fp.accept(")") {
+			return nil, fp.errorf("expected ')'")
+		}
+		return expr, nil
+	}
+
+	if tok.quoted || !isFilterWord(tok.text) {
+		return nil, fp.errorf("expected a tag name, not '%s'", tok.text)
+	}
+	fp.pos++
+	c := comparison{field: tok.text}
+
+	op, ok := fp.peek()
+	if !ok || op.quoted || !isFilterOp(op.text) {
+		return c, nil // the tag must only be present
+	}
+	fp.pos++
+	c.op = op.text
+
+	switch c.op {
+	case "==":
+		c.op = "="
+	case "!=":
+		c.op, c.negate = "=", true
+	case "!~":
+		c.op, c.negate = "~", true
+	}
+
+	value, ok := fp.peek()
+	if !ok || !value.quoted && !isFilterWord(value.text) {
+		return nil, fp.errorf("expected a value after '%s'", op.text)
+	}
+	fp.pos++
+	c.value = value.text
+
+	if c.op == "~" {
+		re, err := regexp.Compile(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("Bad filter: regular expression at char %d: %v", value.col, err)
+		}
+		c.re = re
+	} else if isDateField(c.field) {
+		date, err := chess.ParseDate(c.value)
+		if err != nil {
+			return nil, fmt.Errorf("Bad filter: date at char %d: %v", value.col, err)
+		}
+		c.date = date
+	} else if n, err := strconv.ParseFloat(c.value, 64); err == nil {
+		c.number, c.isNum = n, true
+	}
+
+	return c, nil
+}
+
+// peek returns the next token without consuming it.
+func (fp *filterParser) peek() (filterToken, bool) {
+	if fp.pos == len(fp.tokens) {
+		return filterToken{}, false
+	}
+	return fp.tokens[fp.pos], true
+}
+
+// accept consumes the next token if it is one of the given
+// words or symbols, ignoring case, and returns whether it did.
+func (fp *filterParser) accept(words ...string) bool {
+	tok, ok := fp.peek()
+	if !ok || tok.quoted {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(tok.text, w) {
+			fp.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// errorf makes an error located at the next token.
+func (fp *filterParser) errorf(format string, args ...interface{}) error {
+	col := len(fp.text) + 1
+	if tok, ok := fp.peek(); ok {
+		col = tok.col
+	}
+	return fmt.Errorf("Bad filter: "+format+" at char %d", append(args, col)...)
+}
+
+// isFilterOp returns whether s is a comparison operator.
+func isFilterOp(s string) bool {
+	switch s {
+	case "=", "==", "!=", "<", "<=", ">", ">=", "~", "!~":
+		return true
+	}
+	return false
+}
+
+// isFilterWord returns whether s can be a tag name or a bare
+// value, which rules out parentheses, operators and keywords.
+func isFilterWord(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "not":
+		return false
+	}
+	return s != "" && !isFilterBreak(s[0])
+}
+
+// isFilterBreak returns whether ch ends a word in a filter.
+func isFilterBreak(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' ||
+		ch == '(' || ch == ')' || ch == '"' || strings.IndexByte(filterOpChars, ch) > -1
+}
+
+// filterOpChars are the characters operators are made of.
+const filterOpChars = "=!<>~&|"
@@ -0,0 +1,39 @@
+package pgn
+
+import "testing"
+
+func TestFilterDates(t *testing.T) {
+	for _, test := range []struct {
+		filter string
+		date   string
+		want   bool
+	}{
+		{"Date = 2013", "2013.05.21", true},
+		{"Date = 2013", "2014.05.21", false},
+		{"Date >= 2015", "2016.01.01", true},
+		{"Date >= 2015", "2013.05.21", false},
+		{"Date < 2013.06", "2013.05.21", true},
+		{"Date != 2013", "2013.05.21", false},
+
+		{"Date = 2013", "2013.05.??", true},
+		{"Date = 2013.05.21", "2013.05.??", true},
+		{"Date = 2013.06", "2013.05.??", false},
+		{"Date >= 2015", "2013.??.??", false},
+		{"Date != 2013", "2014.??.??", true},
+
+		{"Date = 2013", "????.??.??", false},
+		{"Date >= 2015", "????.??.??", false},
+		{"Date <= 2015", "????.??.??", false},
+		{"Date != 2013", "????.??.??", true},
+		{"Date", "????.??.??", false},
+		{"not Date", "????.??.??", true},
+	} {
+		f, err := ParseFilter(test.filter)
+		if err != nil {
+			t.Fatalf("%q: %v", test.filter, err)
+		}
+		if got := f.Match(map[string]string{"Date": test.date}); got != test.want {
+			t.Errorf("%q with Date %q: got %v, want %v", test.filter, test.date, got, test.want)
+		}
+	}
+}
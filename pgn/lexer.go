@@ -0,0 +1,446 @@
+package pgn
+
+import (
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// newLexer constructs a lexer which reads tokens from input.
+// Use one lexer per file so that line and column numbers
+// will be accurate.
+func newLexer(input io.Reader) *lexer {
+	return &lexer{
+		input:  input,
+		buf:    make([]byte, lexerBufSize),
+		mark:   -1,
+		line:   1,
+		col:    1,
+		intern: make(map[string]string),
+	}
+}
+
+// lexer splits PGN input into tokens as described by the
+// PGN standard, section 7. It keeps track of the line and
+// column of each token so that errors can be precise.
+//
+// The lexer works on bytes in its own buffer rather than
+// on runes, since everything but comments and tag values
+// is ASCII, and the text of a token is sliced right out of
+// the buffer. Symbols and short strings repeat so much from
+// game to game that they are interned to save allocations.
+type lexer struct {
+	input    io.Reader
+	inputErr error  // error from input, returned once buf is drained
+	buf      []byte // buffered input; buf[pos:end] is yet to be read
+	pos, end int
+	mark     int // start of the current token in buf, or -1
+	line     int // line of the next byte
+	col      int // column of the next character
+
+	intern  map[string]string
+	scratch []byte // for unescaping strings
+}
+
+// next returns the next token from the input. At the end of
+// the input, the token type is tokenEOF. Errors that aren't
+// syntax errors are from the underlying reader.
+func (l *lexer) next() (token, error) {
+	for {
+		ch, ok := l.peek()
+		if !ok {
+			if l.inputErr != io.EOF {
+				return token{}, l.inputErr
+			}
+			return token{typ: tokenEOF, line: l.line, col: l.col}, nil
+		}
+
+		tok := token{line: l.line, col: l.col}
+
+		switch {
+		case ch == ' ' || ch == '\n' || ch == '\r' || ch == '\t' || ch == '\f' || ch == '\v':
+			l.advance()
+			continue
+
+		case ch >= utf8.RuneSelf && l.skipSpaceRune():
+			continue
+
+		case ch == '%' && tok.col == 1:
+			// Escape mechanism: the rest of the line is ignored
+			l.readUntil('\n')
+			continue
+
+		case ch == '.':
+			// Stray periods belong to no move number; skip them
+			l.advance()
+			continue
+
+		case ch == openTag:
+			l.advance()
+			tok.typ = tokenTagOpen
+		case ch == closeTag:
+			l.advance()
+			tok.typ = tokenTagClose
+		case ch == openVariation:
+			l.advance()
+			tok.typ = tokenVariationOpen
+		case ch == closeVariation:
+			l.advance()
+			tok.typ = tokenVariationClose
+		case ch == '*':
+			l.advance()
+			tok.typ, tok.text = tokenResult, chess.Other
+
+		case ch == '"':
+			l.advance()
+			text, ok := l.readString()
+			if !ok {
+				return tok, errAt(tok, "unterminated string")
+			}
+			tok.typ, tok.text = tokenString, text
+
+		case ch == '{':
+			l.advance()
+			text, ok := l.readUntil('}')
+			if !ok {
+				return tok, errAt(tok, "unterminated comment")
+			}
+			tok.typ, tok.text = tokenComment, string(text)
+
+		case ch == ';':
+			l.advance()
+			text, _ := l.readUntil('\n')
+			if n := len(text); n > 0 && text[n-1] == '\r' {
+				text = text[:n-1]
+			}
+			tok.typ, tok.text = tokenComment, string(text)
+
+		case ch == '$':
+			l.mark = l.pos
+			l.advance()
+			text := l.readWhileMarked(isDigit)
+			if len(text) == 1 {
+				return tok, errAt(tok, "expected digits after '$'")
+			}
+			tok.typ, tok.text = tokenNAG, l.internBytes(text)
+
+		case ch == '!' || ch == '?':
+			// Suffix annotations like "!?" are shorthand for NAGs
+			tok.typ, tok.text = tokenNAG, l.internBytes(l.readWhile(isSuffixAnnotation))
+
+		case isSymbolStart(ch):
+			text := l.readSymbol()
+			tok.typ = tokenSymbol
+
+			if isDigits(text) {
+				// A move number, with the periods that follow it
+				tok.typ = tokenMoveNumber
+				l.mark = l.pos - len(text)
+				text = l.readWhileMarked(isPeriod)
+			}
+
+			tok.text = l.internBytes(text)
+			if isResult(tok.text) {
+				tok.typ = tokenResult
+			}
+
+		default:
+			l.advance()
+			return tok, errAt(tok, "unexpected character '"+string(ch)+"'")
+		}
+
+		return tok, nil
+	}
+}
+
+// peek returns the next byte without consuming it. It
+// returns false at the end of the input or on an error,
+// which is then in l.inputErr.
+func (l *lexer) peek() (byte, bool) {
+	if l.pos == l.end && !l.fill() {
+		return 0, false
+	}
+	return l.buf[l.pos], true
+}
+
+// advance consumes the next byte, which must have been
+// peeked, and updates the position.
+func (l *lexer) advance() {
+	ch := l.buf[l.pos]
+	l.pos++
+	if ch == '\n' {
+		l.line++
+		l.col = 1
+	} else if ch&0xC0 != 0x80 {
+		// Continuation bytes of UTF-8 sequences don't count
+		l.col++
+	}
+}
+
+// fill reads more input into the buffer, keeping the bytes
+// from the mark onward so that the current token stays
+// contiguous. It returns false if no more input was read.
+func (l *lexer) fill() bool {
+	if l.inputErr != nil {
+		return false
+	}
+
+	keep := l.pos
+	if l.mark >= 0 {
+		keep = l.mark
+	}
+	if keep > 0 {
+		l.end = copy(l.buf, l.buf[keep:l.end])
+		l.pos -= keep
+		if l.mark >= 0 {
+			l.mark = 0
+		}
+	}
+	if l.end == len(l.buf) {
+		// The token is as big as the buffer; make room
+		bigger := make([]byte, 2*len(l.buf))
+		copy(bigger, l.buf[:l.end])
+		l.buf = bigger
+	}
+
+	for i := 0; i < maxEmptyReads; i++ {
+		n, err := l.input.Read(l.buf[l.end:])
+		l.end += n
+		if err != nil {
+			l.inputErr = err
+		}
+		if n > 0 {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+	}
+
+	l.inputErr = io.ErrNoProgress
+	return false
+}
+
+// readWhile consumes bytes as long as they satisfy fn and
+// returns them. The returned slice is only valid until the
+// next read. Reaching the end of the input is not an error.
+func (l *lexer) readWhile(fn func(byte) bool) []byte {
+	l.mark = l.pos
+	return l.readWhileMarked(fn)
+}
+
+// readWhileMarked is like readWhile, but the bytes returned
+// start at the mark, which must already be set.
+func (l *lexer) readWhileMarked(fn func(byte) bool) []byte {
+	for {
+		ch, ok := l.peek()
+		if !ok || !fn(ch) {
+			break
+		}
+		l.advance()
+	}
+	text := l.buf[l.mark:l.pos]
+	l.mark = -1
+	return text
+}
+
+// readUntil consumes bytes up to and including end and
+// returns what came before end. The returned slice is only
+// valid until the next read. It returns false if the end of
+// the input came first.
+func (l *lexer) readUntil(end byte) ([]byte, bool) {
+	l.mark = l.pos
+	for {
+		ch, ok := l.peek()
+		if !ok || ch == end {
+			text := l.buf[l.mark:l.pos]
+			l.mark = -1
+			if ok {
+				l.advance()
+			}
+			return text, ok
+		}
+		l.advance()
+	}
+}
+
+// readString reads the rest of a quoted string; the opening
+// quote must already be consumed. Quotes inside the string
+// are escaped as \" and backslashes as \\. It returns false
+// if the string is not terminated.
+func (l *lexer) readString() (string, bool) {
+	escapes := false
+
+	l.mark = l.pos
+	for {
+		ch, ok := l.peek()
+		if !ok {
+			l.mark = -1
+			return "", false
+		}
+		if ch == '"' {
+			break
+		}
+		l.advance()
+		if ch == '\\' {
+			escapes = true
+			if _, ok := l.peek(); ok {
+				l.advance()
+			}
+		}
+	}
+	text := l.buf[l.mark:l.pos]
+	l.mark = -1
+	l.advance() // closing quote
+
+	if escapes {
+		l.scratch = l.scratch[:0]
+		for i := 0; i < len(text); i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+			}
+			l.scratch = append(l.scratch, text[i])
+		}
+		text = l.scratch
+	}
+
+	return l.internBytes(text), true
+}
+
+// readSymbol consumes a symbol token and returns it. The
+// returned slice is only valid until the next read. Non-ASCII
+// characters are part of the symbol unless they are spaces,
+// which end it like ASCII spaces do.
+func (l *lexer) readSymbol() []byte {
+	l.mark = l.pos
+	for {
+		ch, ok := l.peek()
+		if !ok || !isSymbolContinue(ch) {
+			break
+		}
+		if ch >= utf8.RuneSelf && l.spaceRuneSize() > 0 {
+			break
+		}
+		l.advance()
+	}
+	text := l.buf[l.mark:l.pos]
+	l.mark = -1
+	return text
+}
+
+// skipSpaceRune consumes the next character if it is a
+// non-ASCII space or a byte order mark, and returns
+// whether it did.
+func (l *lexer) skipSpaceRune() bool {
+	size := l.spaceRuneSize()
+	for i := 0; i < size; i++ {
+		l.advance()
+	}
+	return size > 0
+}
+
+// spaceRuneSize returns the size in bytes of the next
+// character if it is a non-ASCII space or a byte order
+// mark, or 0 if it isn't. It consumes nothing.
+func (l *lexer) spaceRuneSize() int {
+	if l.end-l.pos < utf8.UTFMax {
+		l.fill() // keeps the bytes from the mark, if set
+	}
+	r, size := utf8.DecodeRune(l.buf[l.pos:l.end])
+	if !unicode.IsSpace(r) && r != '\uFEFF' {
+		return 0
+	}
+	return size
+}
+
+// internBytes returns b as a string, reusing an earlier string
+// with the same contents if there is one. Only short strings
+// are interned, and only up to a limit, so memory stays bounded.
+func (l *lexer) internBytes(b []byte) string {
+	if len(b) > maxInternLen {
+		return string(b)
+	}
+	if s, ok := l.intern[string(b)]; ok { // the conversion doesn't allocate here
+		return s
+	}
+	s := string(b)
+	if len(l.intern) < maxInterned {
+		l.intern[s] = s
+	}
+	return s
+}
+
+// errAt makes a syntax error located at the start of tok.
+func errAt(tok token, msg string) error {
+	return &ParseError{Line: tok.line, Col: tok.col, Msg: msg}
+}
+
+// isSymbolStart returns whether ch may begin a symbol
+// token, like a tag name, a move, or a move number.
+// Non-ASCII bytes are allowed since some files use
+// characters like ‡ for checkmate; readSymbol ends
+// a symbol at non-ASCII spaces.
+func isSymbolStart(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || isDigit(ch) || ch >= utf8.RuneSelf
+}
+
+// isSymbolContinue returns whether ch may continue a symbol token.
+func isSymbolContinue(ch byte) bool {
+	return isSymbolStart(ch) || ch == '_' || ch == '+' || ch == '#' ||
+		ch == '=' || ch == ':' || ch == '-' || ch == '/'
+}
+
+func isDigit(ch byte) bool { return '0' <= ch && ch <= '9' }
+
+func isPeriod(ch byte) bool { return ch == '.' }
+
+func isSuffixAnnotation(ch byte) bool { return ch == '!' || ch == '?' }
+
+// isResult returns whether s is a game termination marker.
+func isResult(s string) bool {
+	return s == chess.WhiteWin || s == chess.BlackWin || s == chess.Draw || s == chess.Other
+}
+
+// isDigits returns whether b consists only of decimal digits.
+func isDigits(b []byte) bool {
+	for _, ch := range b {
+		if !isDigit(ch) {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+// token is a single lexical unit of PGN input.
+type token struct {
+	typ       tokenType
+	text      string
+	line, col int
+}
+
+// tokenType is the kind of a token.
+type tokenType int
+
+// Kinds of tokens
+const (
+	tokenEOF            tokenType = iota
+	tokenTagOpen                  // [
+	tokenTagClose                 // ]
+	tokenString                   // quoted tag value, without the quotes
+	tokenSymbol                   // tag name or SAN move
+	tokenMoveNumber               // like 12. or 12...
+	tokenNAG                      // like $14, or a suffix annotation like !?
+	tokenComment                  // {comment} or ; comment, without delimiters
+	tokenVariationOpen            // (
+	tokenVariationClose           // )
+	tokenResult                   // 1-0, 0-1, 1/2-1/2, or *
+)
+
+const (
+	lexerBufSize  = 64 * 1024 // initial size of the lexer's buffer
+	maxEmptyReads = 100       // reads returning nothing before giving up
+	maxInternLen  = 32        // longest string to intern
+	maxInterned   = 1 << 16   // most strings to intern
+)
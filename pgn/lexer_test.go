@@ -0,0 +1,35 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNonASCIISpaceEndsSymbol(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		moves []string
+	}{
+		{"1. e4\u00a0e5 2. Nf3 *", []string{"e4", "e5", "Nf3"}},
+		{"1.\u00a0e4 e5\u00a02. Nf3\ufeff*", []string{"e4", "e5", "Nf3"}},
+		{"1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7\u2021 1-0", []string{"e4", "e5", "Qh5", "Nc6", "Bc4", "Nf6", "Qxf7\u2021"}},
+	} {
+		games, err := Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%q: %v", test.input, err)
+			continue
+		}
+		if len(games) != 1 {
+			t.Errorf("%q: got %d games, want 1", test.input, len(games))
+			continue
+		}
+
+		var moves []string
+		for _, m := range games[0].Moves {
+			moves = append(moves, m.Text)
+		}
+		if strings.Join(moves, " ") != strings.Join(test.moves, " ") {
+			t.Errorf("%q: got moves %q, want %q", test.input, moves, test.moves)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package pgn
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Open opens the PGN file at path for reading. Files compressed
+// with gzip or bzip2 are decompressed as they are read, and the
+// .pgn members of a zip archive are read one after another as if
+// they were a single file. The compression is detected from the
+// first bytes of the file, so a misnamed file is still read
+// correctly; files that aren't compressed are read as they are.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &readCloser{Reader: gz, closers: []io.Closer{gz, f}}, nil
+
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return &readCloser{Reader: bzip2.NewReader(br), closers: []io.Closer{f}}, nil
+
+	case bytes.HasPrefix(magic, zipMagic):
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return &zipMembers{file: f, members: pgnMembers(zr)}, nil
+	}
+
+	return &readCloser{Reader: br, closers: []io.Closer{f}}, nil
+}
+
+// IsPGNFile returns whether path names a file that Open can
+// read games from, judging by its extension: .pgn, optionally
+// followed by .gz or .bz2, or a .zip archive.
+func IsPGNFile(path string) bool {
+	path = strings.ToLower(path)
+	switch filepath.Ext(path) {
+	case ".pgn", ".zip":
+		return true
+	case ".gz", ".bz2":
+		return filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))) == ".pgn"
+	}
+	return false
+}
+
+// pgnMembers returns the files in a zip archive that hold PGN
+// text, skipping directories and anything else.
+func pgnMembers(zr *zip.Reader) []*zip.File {
+	var members []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.ToLower(filepath.Ext(f.Name)) != ".pgn" {
+			continue
+		}
+		members = append(members, f)
+	}
+	return members
+}
+
+// readCloser reads from a decompressor and closes it along
+// with the file underneath.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (rc *readCloser) Close() error {
+	var first error
+	for _, c := range rc.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// zipMembers reads the members of a zip archive in sequence.
+// A blank line is put between members so that the last game
+// of one member can't run into the first game of the next.
+type zipMembers struct {
+	file    *os.File
+	members []*zip.File
+	current io.ReadCloser
+	between bool // whether the separator is due before the next member
+}
+
+func (z *zipMembers) Read(p []byte) (int, error) {
+	for {
+		if z.current != nil {
+			n, err := z.current.Read(p)
+			if err == io.EOF {
+				err = z.current.Close()
+				z.current = nil
+				z.between = true
+			}
+			if n > 0 || err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		if len(z.members) == 0 {
+			return 0, io.EOF
+		}
+
+		if z.between {
+			z.between = false
+			return copy(p, "\n\n"), nil
+		}
+
+		member := z.members[0]
+		z.members = z.members[1:]
+		rc, err := member.Open()
+		if err != nil {
+			return 0, errors.New("Opening " + member.Name + ": " + err.Error())
+		}
+		z.current = rc
+	}
+}
+
+func (z *zipMembers) Close() error {
+	if z.current != nil {
+		z.current.Close()
+		z.current = nil
+	}
+	return z.file.Close()
+}
+
+// Magic numbers at the start of compressed files
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zipMagic   = []byte("PK\x03\x04")
+)
@@ -0,0 +1,108 @@
+package pgn
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	const games = "[White \"A\"]\n\n1. e4 *\n\n[White \"B\"]\n\n1. d4 *\n"
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write([]byte(games))
+	gw.Close()
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	for _, member := range []struct{ name, text string }{
+		{"one.pgn", "[White \"A\"]\n\n1. e4 *"}, // no newline at the end
+		{"notes.txt", "[White \"X\"]\n\n1. c4 *\n"},
+		{"dir/two.PGN", "[White \"B\"]\n\n1. d4 *\n"},
+	} {
+		w, err := zw.Create(member.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(member.text))
+	}
+	zw.Close()
+
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{"plain.pgn", []byte(games)},
+		{"games.pgn.gz", gz.Bytes()},
+		{"misnamed.pgn", gz.Bytes()},
+		{"games.zip", zipped.Bytes()},
+	} {
+		path := filepath.Join(dir, test.name)
+		if err := os.WriteFile(path, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := Open(path)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		parsed, err := Parse(f)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if err := f.Close(); err != nil {
+			t.Errorf("%s: closing: %v", test.name, err)
+		}
+
+		var white []string
+		for _, g := range parsed {
+			white = append(white, g.Tags["White"])
+		}
+		if strings.Join(white, ",") != "A,B" {
+			t.Errorf("%s: got games %v, want [A B]", test.name, white)
+		}
+	}
+}
+
+func TestOpenEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.pgn")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if data, err := io.ReadAll(f); err != nil || len(data) > 0 {
+		t.Errorf("Got %q, %v; want nothing", data, err)
+	}
+}
+
+func TestIsPGNFile(t *testing.T) {
+	for _, test := range []struct {
+		path string
+		want bool
+	}{
+		{"games.pgn", true},
+		{"GAMES.PGN", true},
+		{"games.pgn.gz", true},
+		{"games.pgn.bz2", true},
+		{"games.zip", true},
+		{"games.txt", false},
+		{"games.gz", false},
+		{"games.tar.bz2", false},
+	} {
+		if got := IsPGNFile(test.path); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.path, got, test.want)
+		}
+	}
+}
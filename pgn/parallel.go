@@ -0,0 +1,253 @@
+package pgn
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// ParallelOptions configures ParseParallel.
+type ParallelOptions struct {
+	Workers   int     // number of goroutines parsing; default is the number of CPUs
+	ChunkSize int     // approximate size of each chunk in bytes; default is 4 MB
+	Ordered   bool    // whether games are passed on in their order in the input
+	Lenient   bool    // whether to skip games with syntax errors, as Reader does
+	Filter    *Filter // if set, only games matching it are parsed, as with Reader
+}
+
+// ParseParallel parses the games in input on a pool of workers
+// and calls fn with each game. The input is split into chunks
+// on game boundaries, which are a blank line followed by a line
+// starting with '['; a game containing such a thing inside a
+// comment will be split in two and fail to parse.
+//
+// Calls to fn are made from one goroutine, so fn needs no
+// locking. If opts.Ordered is set, games are passed to fn in
+// the order they appear in the input; otherwise they are passed
+// on as soon as they are parsed. If fn returns an error, parsing
+// stops and the error is returned.
+//
+// In lenient mode, the errors of skipped games are returned,
+// sorted and indexed as Reader would. Otherwise the first syntax
+// error ends parsing, though if the games are unordered, some
+// games that came after it in the input may already be passed on.
+func ParseParallel(input io.Reader, opts ParallelOptions, fn func(chess.Game) error) ([]*GameError, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = defaultChunkSize
+	}
+
+	chunks := make(chan chunk, opts.Workers)
+	results := make(chan chunkResult, opts.Workers)
+	done := make(chan struct{})
+
+	var splitErr error
+	go func() {
+		splitErr = splitChunks(input, opts.ChunkSize, chunks, done)
+		close(chunks)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				select {
+				case results <- parseChunk(c, opts):
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var (
+		counts    []int                        // number of games in each chunk, by sequence
+		chunkErrs = make(map[int][]*GameError) // errors of skipped games, by sequence
+		pending   = make(map[int]chunkResult)  // results waiting their turn, if ordered
+		nextSeq   int                          // sequence of the next chunk in order
+	)
+
+	handle := func(r chunkResult) error {
+		for _, game := range r.games {
+			if err := fn(game); err != nil {
+				return err
+			}
+		}
+		return r.err
+	}
+
+	for r := range results {
+		for len(counts) <= r.seq {
+			counts = append(counts, 0)
+		}
+		counts[r.seq] = r.count
+		if len(r.gameErrs) > 0 {
+			chunkErrs[r.seq] = r.gameErrs
+		}
+
+		var err error
+		if opts.Ordered {
+			pending[r.seq] = r
+			for err == nil {
+				next, ok := pending[nextSeq]
+				if !ok {
+					break
+				}
+				delete(pending, nextSeq)
+				nextSeq++
+				err = handle(next)
+			}
+		} else {
+			err = handle(r)
+		}
+
+		if err != nil {
+			close(done)
+			for range results {
+				// let the workers finish
+			}
+			return indexErrors(counts, chunkErrs), err
+		}
+	}
+
+	return indexErrors(counts, chunkErrs), splitErr
+}
+
+// splitChunks reads input and sends it on chunks in pieces of
+// about size bytes that each hold whole games. It stops early
+// if done is closed.
+func splitChunks(input io.Reader, size int, chunks chan<- chunk, done <-chan struct{}) error {
+	var carry []byte // input after the last boundary, for the next chunk
+	line, seq := 1, 0
+
+	for {
+		n := size
+		if 2*len(carry) > n {
+			n = 2 * len(carry)
+		}
+		buf := make([]byte, n)
+		have := copy(buf, carry)
+
+		read, err := io.ReadFull(input, buf[have:])
+		buf = buf[:have+read]
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return err
+		}
+
+		cut := len(buf)
+		if !eof {
+			cut = lastBoundary(buf)
+			if cut <= 0 {
+				// No boundary yet; keep reading into a bigger buffer
+				carry = buf
+				continue
+			}
+		}
+
+		if cut > 0 {
+			select {
+			case chunks <- chunk{seq: seq, line: line, data: buf[:cut]}:
+			case <-done:
+				return nil
+			}
+			seq++
+			line += bytes.Count(buf[:cut], []byte{'\n'})
+		}
+
+		if eof {
+			return nil
+		}
+		carry = buf[cut:]
+	}
+}
+
+// lastBoundary returns the index of the '[' that begins the last
+// game in buf which is preceded by a blank line, or -1 if there
+// is none.
+func lastBoundary(buf []byte) int {
+	i := bytes.LastIndex(buf, []byte("\n\n["))
+	if i > -1 {
+		i += 2
+	}
+	if j := bytes.LastIndex(buf, []byte("\n\r\n[")); j > -1 && j+3 > i {
+		i = j + 3
+	}
+	return i
+}
+
+// parseChunk parses all the games in a chunk.
+func parseChunk(c chunk, opts ParallelOptions) chunkResult {
+	result := chunkResult{seq: c.seq}
+
+	reader := NewReader(bytes.NewReader(c.data))
+	reader.Lenient = opts.Lenient
+	reader.Filter = opts.Filter
+	reader.parser.lexer.line = c.line
+
+	for {
+		game, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			result.err = err
+			break
+		}
+		result.games = append(result.games, game)
+	}
+
+	result.gameErrs = reader.Errors
+	result.count = reader.index
+
+	return result
+}
+
+// indexErrors renumbers the errors of each chunk by their index in
+// the whole input, given the number of games in each chunk, and
+// returns them all in order.
+func indexErrors(counts []int, chunkErrs map[int][]*GameError) []*GameError {
+	var all []*GameError
+	offset := 0
+	for seq, count := range counts {
+		for _, gameErr := range chunkErrs[seq] {
+			gameErr.Index += offset
+			all = append(all, gameErr)
+		}
+		offset += count
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Index < all[j].Index
+	})
+	return all
+}
+
+// chunk is a piece of PGN input holding whole games.
+type chunk struct {
+	seq  int    // sequence number of the chunk in the input
+	line int    // line number of the start of the chunk
+	data []byte // the PGN text
+}
+
+// chunkResult is the games parsed from a chunk.
+type chunkResult struct {
+	seq      int
+	games    []chess.Game
+	gameErrs []*GameError // errors of skipped games, if lenient
+	count    int          // number of games, including skipped and filtered ones
+	err      error        // error that ended parsing early, if any
+}
+
+// defaultChunkSize is the default approximate size of a chunk.
+const defaultChunkSize = 4 << 20
@@ -0,0 +1,90 @@
+package pgn
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// parallelInput makes n small games numbered by their Round tag,
+// where every game whose number is a multiple of bad is broken.
+func parallelInput(n, bad int) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "[Event \"Test\"]\n[Round \"%d\"]\n\n", i)
+		if i%bad == 0 {
+			buf.WriteString("1. e4 e5 2. ) Nf3 *\n\n")
+		} else {
+			buf.WriteString("1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 *\n\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestParseParallel(t *testing.T) {
+	input := parallelInput(300, 7)
+
+	games, wantErrs, err := ParseLenient(bytes.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []int
+	for _, g := range games {
+		round, _ := strconv.Atoi(g.Tags["Round"])
+		want = append(want, round)
+	}
+
+	for _, ordered := range []bool{true, false} {
+		var got []int
+		opts := ParallelOptions{Workers: 4, ChunkSize: 256, Ordered: ordered, Lenient: true}
+		gameErrs, err := ParseParallel(bytes.NewReader(input), opts, func(g chess.Game) error {
+			round, _ := strconv.Atoi(g.Tags["Round"])
+			got = append(got, round)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Ordered %v: %v", ordered, err)
+		}
+
+		if !ordered {
+			sort.Ints(got)
+		}
+		if !equalInts(got, want) {
+			t.Errorf("Ordered %v: got games %v, want %v", ordered, got, want)
+		}
+
+		if len(gameErrs) != len(wantErrs) {
+			t.Fatalf("Ordered %v: got %d errors, want %d", ordered, len(gameErrs), len(wantErrs))
+		}
+		for i, e := range gameErrs {
+			if e.Index != wantErrs[i].Index || e.Line != wantErrs[i].Line {
+				t.Errorf("Ordered %v: got error for game %d on line %d, want game %d on line %d",
+					ordered, e.Index, e.Line, wantErrs[i].Index, wantErrs[i].Line)
+			}
+		}
+	}
+}
+
+func TestParseParallelStrict(t *testing.T) {
+	input := parallelInput(50, 20)[len("[Event \"Test\"]\n[Round \"0\"]\n\n1. e4 e5 2. ) Nf3 *\n\n"):]
+
+	var count int
+	opts := ParallelOptions{Workers: 2, ChunkSize: 256, Ordered: true}
+	_, err := ParseParallel(bytes.NewReader(input), opts, func(chess.Game) error {
+		count++
+		return nil
+	})
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Got error %v, want a parse error", err)
+	}
+	// Round 20 is the first broken game; each game is five lines,
+	// and its movetext is on the fourth
+	if count != 19 || perr.Line != 19*5+4 {
+		t.Errorf("Got %d games and an error on line %d, want 19 and line %d", count, perr.Line, 19*5+4)
+	}
+}
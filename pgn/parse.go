@@ -1,398 +1,438 @@
 package pgn
 
 import (
-	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
-	"unicode"
 
 	"github.com/mholt/chessml/chess"
 )
 
 // newGameParser constructs, well, a new game parser which
-// consumes tokens from the scanner. Use one gameParser per
-// file so that line and character counts will be accurate.
-func newGameParser(scanner *bufio.Scanner) *gameParser {
+// consumes tokens from the input. Use one gameParser per
+// file so that line and column numbers will be accurate.
+func newGameParser(input io.Reader) *gameParser {
 	return &gameParser{
-		scanner: scanner,
-		line:    1,
-		char:    1,
+		lexer: newLexer(input),
 	}
 }
 
 // gameParser is capable of parsing a single game at a time
-// from a PGN file being read by its scanner. The scanner
-// must not be nil. Use the same gameParser for the entire
-// file.
+// from a PGN file being read by its lexer. Use the same
+// gameParser for the entire file.
 type gameParser struct {
-	scanner *bufio.Scanner
-	game    chess.Game
-	line    int
-	char    int
-	mv      string
+	lexer     *lexer
+	game      chess.Game
+	toMove    chess.Color
+	number    int     // number of the current turn
+	setUp     bool    // whether the game starts from a FEN tag
+	movetext  bool    // whether the tag section has been parsed
+	filter    *Filter // games not matching are skipped, if set
+	last      token   // the last token successfully read
+	unreadTok *token  // a token to be read again, if any
 }
 
 // parseGame will parse the input until an entire game is parsed.
-// parseGame returns the game, whether there is any more input
-// available in the file, and any error that may have occured. If an
-// the EOF was encountered (i.e. the middle return is true) or an
-// error occured, parsing was aborted abruptly and did not finish.
+// parseGame returns the game, whether the end of the input was
+// reached before a game began, and any error that may have occured.
+// If the end of the input was reached (i.e. the middle return is
+// true) or an error occured, the game is not usable. A game whose
+// tags don't match the filter has its movetext skipped without
+// being parsed, and errFiltered is returned.
 func (gp *gameParser) parseGame() (chess.Game, bool, error) {
-	gp.game = chess.Game{}
+	gp.game = chess.Game{Tags: make(map[string]string)}
+	gp.movetext = false
 
-	// We must insist that a game starts with tags
-	// so that we can gracefully handle malformed PGN files
-	// by skipping bad lines between games. That's why this
-	// is in a loop. We skip lines until EOF or err is nil.
-	for {
-		done, err := gp.parseTags()
-		if err != nil {
-			// Skip line as if it was commented
-			currentLine := gp.line
-			for gp.line == currentLine {
-				if !gp.scan() {
-					return gp.game, true, err // no more input
-				}
-			}
-			continue
-		} else if done {
-			// Finished with all games
-			return gp.game, true, nil
-		}
-
-		// If all is well, no need to parse tags again
-		break
-	}
-
-	err := gp.parseMoves()
+	tok, err := gp.next()
 	if err != nil {
 		return gp.game, false, err
 	}
 
-	return gp.game, false, nil
-}
-
-// parseTags parses the tag pairs portion of a PGN file and
-// loads them into the struct. The PGN file format requires
-// that games are preceded by certain required tags, but
-// this parser can detect if they are missing and skip
-// them gracefully. It returns true if there is no more
-// input available, i.e. no more tokens to scan.
-func (gp *gameParser) parseTags() (bool, error) {
-	gp.game.Tags = make(map[string]string)
-
-	for gp.scan() {
-		ch := gp.getch()
-
-		if unicode.IsSpace(ch) {
-			continue
+	// Comments between games belong to no game
+	for tok.typ == tokenComment {
+		tok, err = gp.next()
+		if err != nil {
+			return gp.game, false, err
 		}
+	}
 
-		if ch == ';' {
-			// skip commented line
-			for gp.scan() {
-				ch = gp.getch()
-				if ch == '\n' {
-					break // EOL
-				}
-			}
-			continue
+	if tok.typ == tokenEOF {
+		return gp.game, true, nil
+	}
+
+	// The tag pairs section is optional, though the
+	// standard requires it for games in export format
+	for tok.typ == tokenTagOpen {
+		err = gp.parseTag()
+		if err != nil {
+			return gp.game, false, err
 		}
 
-		if ch == '1' {
-			return false, nil // beginning of movetext
+		tok, err = gp.next()
+		if err != nil {
+			return gp.game, false, err
 		}
+	}
 
-		err := gp.parseTag()
+	if gp.filter != nil && !gp.filter.Match(gp.game.Tags) {
+		gp.movetext = true
+		err = gp.skipMoves(tok)
 		if err != nil {
-			return false, err
+			return gp.game, false, err
 		}
+		return gp.game, false, errFiltered
+	}
+
+	// The tags determine who moves first and the first turn number
+	pos, err := gp.game.StartPosition()
+	if err != nil {
+		return gp.game, false, errAt(tok, err.Error())
 	}
+	gp.toMove = pos.ToMove
+	gp.number = pos.MoveNumber
+	_, gp.setUp = gp.game.Tags["FEN"]
+	gp.setUp = gp.setUp && gp.game.Tags["SetUp"] != "0"
+	gp.movetext = true
 
-	return true, nil
+	err = gp.parseMoves(tok)
+	if err != nil {
+		return gp.game, false, err
+	}
+
+	return gp.game, false, nil
 }
 
-// parseTag parses a tag from the tag pairs portion
-// of a PGN file. It expects the currently-loaded token
-// to be an opening tag character.
+// parseTag parses a tag pair like [Event "F/S Return Match"]
+// and loads it into the game. It expects the open tag token
+// to have already been consumed.
 func (gp *gameParser) parseTag() error {
-	if gp.getch() != openTag {
-		return gp.err("Expecting open tag '" +
-			string(openTag) + "' or beginning of movetext")
+	name, err := gp.expect(tokenSymbol, "Expecting tag name")
+	if err != nil {
+		return err
 	}
 
-	tagName, err := gp.parseTagName()
+	value, err := gp.expect(tokenString, "Expecting quoted tag value")
 	if err != nil {
 		return err
 	}
 
-	tagVal, err := gp.parseTagValue()
+	_, err = gp.expect(tokenTagClose, "Expecting close tag '"+string(closeTag)+"'")
 	if err != nil {
 		return err
 	}
 
-	gp.game.Tags[tagName] = tagVal
-
-	if gp.getch() != closeTag {
-		return gp.err("Expecting close tag '" +
-			string(closeTag) + "' token")
+	if name.text == "FEN" {
+		if _, err := chess.ParseFEN(value.text); err != nil {
+			return errAt(value, err.Error())
+		}
 	}
 
+	gp.game.Tags[name.text] = value.text
+
 	return nil
 }
 
-// parseTagName parses the name of a tag from the tag
-// pairs portion of a PGN file. It returns the tag name.
-// Tag names may not be quoted.
-func (gp *gameParser) parseTagName() (string, error) {
-	var tagName string
+// next reads the next token, which is the unread
+// token if there is one.
+func (gp *gameParser) next() (token, error) {
+	if gp.unreadTok != nil {
+		tok := *gp.unreadTok
+		gp.unreadTok = nil
+		return tok, nil
+	}
 
-	for gp.scan() {
-		ch := gp.getch()
+	tok, err := gp.lexer.next()
+	if err == nil {
+		gp.last = tok
+	}
+	return tok, err
+}
 
-		if unicode.IsSpace(ch) {
-			if len(tagName) == 0 {
-				continue
-			} else {
-				break
+// resync skips input after a syntax error until the tag
+// section of the next game, so that parsing can resume
+// there. It returns only errors from reading the input.
+func (gp *gameParser) resync() error {
+	// Errors in the tag section happen inside a tag
+	movetext := gp.movetext
+	inTag := !gp.movetext
+	tok := gp.last
+
+	for {
+		switch tok.typ {
+		case tokenEOF:
+			gp.unreadTok = &tok
+			return nil
+		case tokenTagOpen:
+			// A tag at the start of a line after the movetext
+			// of the broken game begins the next game
+			if movetext && tok.col == 1 {
+				gp.unreadTok = &tok
+				return nil
+			}
+			inTag = true
+		case tokenTagClose:
+			inTag = false
+		case tokenString:
+		default:
+			if !inTag {
+				movetext = true
 			}
 		}
 
-		tagName += string(ch)
+		// Syntax errors in the skipped input don't matter
+		for {
+			var err error
+			tok, err = gp.next()
+			if _, ok := err.(*ParseError); !ok {
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
 	}
+}
 
-	return tagName, nil
+// expect consumes the next token, which must be of type typ;
+// if it is not, an error with msg is returned.
+func (gp *gameParser) expect(typ tokenType, msg string) (token, error) {
+	tok, err := gp.next()
+	if err != nil {
+		return tok, err
+	}
+	if tok.typ != typ {
+		return tok, errAt(tok, msg+haveToken(tok))
+	}
+	return tok, nil
 }
 
-// parseTagValue parses the value of a tag from the tag
-// pairs portion of a PGN file. It returns the tag value.
-// Tag values MUST be quoted. Quotes should be escaped as
-// \" and backslashes as \\.
-func (gp *gameParser) parseTagValue() (string, error) {
-	var tagValue string
-	var escaped, quoted bool
-	var quotes rune
-
-	for gp.scan() {
-		ch := gp.getch()
-
-		if !quoted {
-			if ch == '"' || ch == '\'' {
-				quoted = true
-				quotes = ch
-			}
-			continue
-		}
+// parseMoves parses the movetext of a game, starting with tok,
+// until the game termination marker (1-0, 0-1, 1/2-1/2, or *).
+// Move numbers are optional; the color and number of each move
+// are known from the one before it. The first move is numbered
+// as written and belongs to Black if its number is written with
+// three periods ("12..."), unless the game starts from a FEN tag,
+// which then determines both. Comments and NAGs are attached
+// to the move they follow, and variations are skipped. The end
+// of the input is accepted in place of a termination marker.
+func (gp *gameParser) parseMoves(tok token) error {
+	var err error
 
-		if quoted {
-			if !escaped {
-				if ch == '\\' {
-					escaped = true
-					continue
-				}
-				if ch == quotes {
-					quoted = false
-					break
+	for {
+		switch tok.typ {
+		case tokenEOF, tokenResult:
+			return nil
+
+		case tokenMoveNumber:
+			if len(gp.game.Moves) == 0 && !gp.setUp {
+				gp.number, _ = strconv.Atoi(strings.TrimRight(tok.text, "."))
+				if strings.HasSuffix(tok.text, "...") {
+					gp.toMove = chess.BlackTeam
 				}
 			}
-			escaped = false
-		}
 
-		tagValue += string(ch)
-	}
+		case tokenSymbol:
+			if !isMovetext(tok.text) {
+				return errAt(tok, "Expected a move"+haveToken(tok))
+			}
+			gp.addMove(tok.text)
 
-	gp.scan() // skip closing quote
+		case tokenNAG:
+			if len(gp.game.Moves) == 0 {
+				return errAt(tok, "Annotation before the first move"+haveToken(tok))
+			}
+			nag, ok := nagValue(tok.text)
+			if !ok {
+				return errAt(tok, "Unknown annotation"+haveToken(tok))
+			}
+			m := &gp.game.Moves[len(gp.game.Moves)-1]
+			m.NAGs = append(m.NAGs, nag)
 
-	return tagValue, nil
-}
+		case tokenComment:
+			err = gp.addComment(tok)
+			if err != nil {
+				return err
+			}
 
-// parseMoves parses the movetext of a PGN file.
-// It terminates immediately after parsing the result
-// of the match. It expects the currently-loaded
-// token to be the number '1', which is what indicates
-// the beginning of the movetext. Movetext MUST end
-// with the result, one of 1-0, 0-1, 1/2-1/2, or *.
-func (gp *gameParser) parseMoves() error {
-	if gp.getch() != '1' {
-		return gp.err("Expecting beginning of movetext")
-	}
+		case tokenVariationOpen:
+			err = gp.skipVariation()
+			if err != nil {
+				return err
+			}
 
-	gp.scan() // advance past the '1'
+		case tokenTagOpen:
+			return errAt(tok, "Expected a move or end-of-game result; not a tag")
 
-	for {
-		end, err := gp.parseTurn()
-		if err != nil {
-			return err
+		default:
+			return errAt(tok, "Unexpected token in movetext"+haveToken(tok))
 		}
 
-		if end {
-			break
+		tok, err = gp.next()
+		if err != nil {
+			return err
 		}
 	}
-
-	return nil
 }
 
-// parseTurn parses a turn, which is two moves (presumably
-// one by each player). It expects the currently-loaded token
-// to be a period '.' which indicates the beginning of a turn,
-// as it immediately follows the turn number. Parsed turns are
-// automatically loaded into the game struct. parseTurn returns
-// true if all the turns have been parsed for this game.
-func (gp *gameParser) parseTurn() (bool, error) {
+// skipMoves consumes the movetext of a game, starting with
+// tok, through the game termination marker. The moves are
+// not parsed, so only errors from the lexer are found.
+func (gp *gameParser) skipMoves(tok token) error {
 	var err error
 
-	// Turns start with a '.' which must come after the turn
-	// number, but sometimes there is a space after the number and
-	// dot, like "1. c4"
-	if gp.mv == "" && gp.getch() != moveStart {
-		if !unicode.IsSpace(gp.getch()) {
-			return false, gp.err("Expected turn starting with a dot '.'")
+	for {
+		switch tok.typ {
+		case tokenEOF, tokenResult:
+			return nil
+		case tokenVariationOpen:
+			err = gp.skipVariation()
+			if err != nil {
+				return err
+			}
+		case tokenTagOpen:
+			return errAt(tok, "Expected a move or end-of-game result; not a tag")
 		}
-	}
 
-	// White's move is the first move of a turn, but we may have
-	// already consumed it from the last turn (gp.mv), so use it.
-	var whiteMove string
-	if gp.mv != "" {
-		whiteMove = gp.mv
-		gp.mv = ""
-	} else {
-		whiteMove, err = gp.parseMove()
+		tok, err = gp.next()
 		if err != nil {
-			return false, err
+			return err
 		}
 	}
+}
 
-	// Save white's move
-	gp.game.Moves = append(gp.game.Moves, chess.Move{
-		Player:      chess.White,
-		PlayerColor: chess.WhiteTeam,
-		Text:        whiteMove,
-	})
+// skipVariation skips a recursive annotation variation,
+// including any nested within it. It expects the opening
+// token of the variation to have already been consumed.
+func (gp *gameParser) skipVariation() error {
+	depth := 1
 
-	// Parse what we assume to be black's move
-	blackMove, err := gp.parseMove()
-	if err != nil {
-		return false, err
+	for depth > 0 {
+		tok, err := gp.next()
+		if err != nil {
+			return err
+		}
+
+		switch tok.typ {
+		case tokenVariationOpen:
+			depth++
+		case tokenVariationClose:
+			depth--
+		case tokenEOF, tokenTagOpen:
+			return errAt(tok, "Unterminated variation")
+		}
 	}
 
-	// There is always at least one move in a turn, so we
-	// didn't check for end-of-game yet. The second one,
-	// however, might be end-of-game instead of black's move...
-	if blackMove == chess.WhiteWin || blackMove == chess.BlackWin ||
-		blackMove == chess.Draw || blackMove == chess.Other {
-		// Game over; turns out we just parsed the result instead
-		return true, nil
+	return nil
+}
+
+// addMove saves a move by the player whose turn it is.
+func (gp *gameParser) addMove(text string) {
+	player := chess.White
+	if gp.toMove == chess.BlackTeam {
+		player = chess.Black
 	}
 
-	// Save black's move
 	gp.game.Moves = append(gp.game.Moves, chess.Move{
-		Player:      chess.Black,
-		PlayerColor: chess.BlackTeam,
-		Text:        blackMove,
+		Player:      player,
+		PlayerColor: gp.toMove,
+		Number:      gp.number,
+		Text:        text,
 	})
 
-	// This file format is terrible. There might even be a third
-	// 'move' in a turn, which is actually just a result. This third
-	// 'move' appears when black wins the game or causes a draw.
-	// But since it's probably not the end result, we'll have over-consumed
-	// so we have to store the actual move, which is the white move of the
-	// next turn, in order to make use of it.
-	mv, err := gp.parseMove()
-	if err != nil {
-		return false, err
-	}
-	if dotIdx := strings.Index(mv, "."); dotIdx > -1 {
-		gp.mv = mv[dotIdx+1:] // strip number out of the move
+	if gp.toMove == chess.WhiteTeam {
+		gp.toMove = chess.BlackTeam
 	} else {
-		return true, nil // result indicates end of game; won't have '.' in it
+		gp.toMove = chess.WhiteTeam
+		gp.number++
 	}
-
-	return false, nil
 }
 
-// parseMove parses a single move in a turn. It basically
-// collects tokens until a space character is encountered.
-// If a move starts with the move number (e.g. "3.Nf3"), the
-// whole thing will be returned; it is the job of the caller
-// to strip out the number and the dot after it (this method
-// has no notion of context and won't try to change anything).
-// This method will skip comments enclosed in { and } characters.
-func (gp *gameParser) parseMove() (string, error) {
-	var mv string
-
-	for gp.scan() {
-		ch := gp.getch()
-
-		if ch == '{' {
-			// Comment in the movetext
-			for {
-				if !gp.scan() {
-					return mv, gp.err("unterminated comment")
-				} else if gp.getch() == '}' {
-					break
-				}
-			}
-			continue
-		}
+// addComment attaches a comment to the last saved move,
+// extracting any embedded commands. Comments that come
+// before the first move are dropped.
+func (gp *gameParser) addComment(tok token) error {
+	if len(gp.game.Moves) == 0 {
+		return nil
+	}
 
-		if unicode.IsSpace(ch) {
-			if len(mv) > 0 {
-				break
-			}
-			continue
-		}
+	m := &gp.game.Moves[len(gp.game.Moves)-1]
 
-		mv += string(ch)
+	text, err := parseCommands(tok.text, m)
+	if err != nil {
+		return errAt(tok, err.Error())
 	}
 
-	if strings.HasPrefix(mv, "[") {
-		return mv, gp.err("Expected a move or end-of-game result; not a tag")
+	if m.Comment != "" && text != "" {
+		m.Comment += " "
 	}
+	m.Comment += text
 
-	return mv, nil
+	return nil
 }
 
-// scan loads the next token into the scanner; it also
-// ups the character position and line number if necessary.
-func (gp *gameParser) scan() bool {
-	ok := gp.scanner.Scan()
-	if ok {
-		gp.char++
-		if gp.scanner.Text() == "\n" {
-			gp.line++
-			gp.char = 0
-		}
+// isMovetext returns whether s looks like a move in
+// standard algebraic notation: a castle, or something
+// starting with a piece letter or a file.
+func isMovetext(s string) bool {
+	if strings.HasPrefix(s, "O-O") || strings.HasPrefix(s, "0-0") {
+		return true
 	}
-	return ok
+	return strings.IndexByte("KQBNRabcdefgh", s[0]) > -1
 }
 
-// err makes an error message with line and character information
-// that's useful for debugging.
-func (gp *gameParser) err(msg string) error {
-	if gp.getch() == 0 {
-		return errors.New(fmt.Sprintf("Parse error - line %d, char %d: "+
-			"unexpected EOF; %s", gp.line, gp.char, msg))
+// nagValue returns the numeric value of a NAG token,
+// which is either like $14 or a suffix annotation like !?.
+func nagValue(s string) (int, bool) {
+	if strings.HasPrefix(s, "$") {
+		n, err := strconv.Atoi(s[1:])
+		return n, err == nil && n < 256
 	}
-	return errors.New(fmt.Sprintf("Parse error - line %d, char %d: %s (have '%s')",
-		gp.line, gp.char, msg, string(gp.getch())))
+	n, ok := suffixNAGs[s]
+	return n, ok
 }
 
-// getch gets the currently-loaded token (character)
-// as a rune. If more than one rune is loaded
-// then only the first will be returned.
-func (gp *gameParser) getch() rune {
-	txt := gp.scanner.Text()
-	if len(txt) == 0 {
-		return 0
+// haveToken describes tok for use in an error message.
+func haveToken(tok token) string {
+	if tok.typ == tokenEOF {
+		return "; unexpected EOF"
+	}
+	if tok.text == "" {
+		return ""
 	}
-	return []rune(txt)[0]
+	return " (have '" + tok.text + "')"
 }
 
+// A ParseError is a syntax error in PGN input, located
+// by line and column (both starting at 1).
+type ParseError struct {
+	Line, Col int
+	Msg       string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("Parse error - line %d, char %d: %s", e.Line, e.Col, e.Msg)
+}
+
+// errFiltered is returned by parseGame for a game that
+// doesn't match the parser's filter.
+var errFiltered = errors.New("Game does not match the filter")
+
 const (
-	openTag   = '['
-	closeTag  = ']'
-	moveStart = '.'
+	openTag        = '['
+	closeTag       = ']'
+	openVariation  = '('
+	closeVariation = ')'
 )
+
+// suffixNAGs maps the traditional suffix annotations
+// to the NAGs they stand for.
+var suffixNAGs = map[string]int{
+	"!":  1,
+	"?":  2,
+	"!!": 3,
+	"??": 4,
+	"!?": 5,
+	"?!": 6,
+}
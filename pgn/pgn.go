@@ -1,7 +1,6 @@
 package pgn
 
 import (
-	"bufio"
 	"io"
 
 	"github.com/mholt/chessml/chess"
@@ -10,27 +9,40 @@ import (
 // Parse parses the PGN file format from input into games.
 // A file may contain zero or more chess games.
 func Parse(input io.Reader) (games []chess.Game, err error) {
-	var game chess.Game
-	var done bool
+	reader := NewReader(input)
 
-	// The scanner reads the input
-	scanner := bufio.NewScanner(input)
-	scanner.Split(bufio.ScanRunes)
+	for {
+		game, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return games, err
+		}
+
+		games = append(games, game)
+	}
+
+	return games, nil
+}
 
-	// The parser assembles the games
-	parser := newGameParser(scanner)
+// ParseLenient is like Parse, except that games with syntax
+// errors are skipped instead of ending the parse. The errors
+// of the skipped games are returned alongside the good games.
+// The final error is only for failures reading the input.
+func ParseLenient(input io.Reader) (games []chess.Game, gameErrs []*GameError, err error) {
+	reader := NewReader(input)
+	reader.Lenient = true
 
 	for {
-		game, done, err = parser.parseGame()
-		if err != nil {
-			return games, err
-		} else if done {
+		game, err := reader.Read()
+		if err == io.EOF {
 			break
+		} else if err != nil {
+			return games, reader.Errors, err
 		}
 
-		game.Board.Setup()
 		games = append(games, game)
 	}
 
-	return games, nil
+	return games, reader.Errors, nil
 }
@@ -0,0 +1,85 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// NewReader returns a Reader that reads games from input.
+func NewReader(input io.Reader) *Reader {
+	return &Reader{parser: newGameParser(input)}
+}
+
+// A Reader reads games one at a time from PGN input, so
+// that a large file need not be held in memory at once.
+type Reader struct {
+	// If Lenient is true, a game with a syntax error is
+	// skipped, reading resumes at the tag section of the
+	// next game, and the error is added to Errors. Otherwise
+	// the first syntax error is returned by Read.
+	Lenient bool
+
+	// Errors are the syntax errors of skipped games.
+	Errors []*GameError
+
+	// If Filter is set, games whose tags don't match it are
+	// skipped; their moves are not even parsed. Skipped games
+	// still count toward the index of the games after them.
+	Filter *Filter
+
+	parser *gameParser
+	index  int // index of the next game in the input
+}
+
+// Read returns the next game in the input. At the end
+// of the input, it returns io.EOF.
+func (r *Reader) Read() (chess.Game, error) {
+	r.parser.filter = r.Filter
+
+	for {
+		game, done, err := r.parser.parseGame()
+		if done {
+			return game, io.EOF
+		}
+		if err == errFiltered {
+			r.index++
+			continue
+		}
+
+		if err == nil {
+			err = game.Reset()
+		}
+
+		if err != nil {
+			perr, ok := err.(*ParseError)
+			if !ok || !r.Lenient {
+				return game, err
+			}
+
+			r.Errors = append(r.Errors, &GameError{Index: r.index, Line: perr.Line, Err: perr})
+			r.index++
+
+			err = r.parser.resync()
+			if err != nil {
+				return game, err
+			}
+			continue
+		}
+
+		r.index++
+		return game, nil
+	}
+}
+
+// A GameError is an error in one game of a PGN file.
+type GameError struct {
+	Index int // index of the game in the file, starting at 0
+	Line  int // line where the error was found
+	Err   error
+}
+
+func (e *GameError) Error() string {
+	return fmt.Sprintf("Game %d (line %d): %v", e.Index, e.Line, e.Err)
+}
@@ -0,0 +1,129 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLenientResync(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		input  string
+		white  []string // White tags of the games read
+		errors []int    // indexes of the games skipped
+		lines  []int    // and the lines of their errors
+	}{
+		{
+			name: "bad movetext",
+			input: `[White "A"]
+
+1. e4 e5 2. ) Nf3 *
+
+[White "B"]
+
+1. d4 d5 *
+`,
+			white:  []string{"B"},
+			errors: []int{0},
+			lines:  []int{3},
+		},
+		{
+			name: "bad tag",
+			input: `[White "A"]
+
+1. e4 e5 *
+
+[White "B" junk]
+[Black "X"]
+
+1. c4 *
+
+[White "C"]
+
+1. d4 *
+`,
+			white:  []string{"A", "C"},
+			errors: []int{1},
+			lines:  []int{5},
+		},
+		{
+			name: "unterminated comment",
+			input: `[White "A"]
+
+1. e4 { never closed
+1-0
+
+[White "B"]
+
+1. d4 *
+`,
+			white:  []string{},
+			errors: []int{0},
+			lines:  []int{3},
+		},
+		{
+			name: "two bad games in a row",
+			input: `[White "A"]
+
+1. e4 ) *
+
+[White "B"]
+
+1. d4 ) *
+
+[White "C"]
+
+1. c4 *
+`,
+			white:  []string{"C"},
+			errors: []int{0, 1},
+			lines:  []int{3, 7},
+		},
+	} {
+		games, gameErrs, err := ParseLenient(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		white := []string{}
+		for _, g := range games {
+			white = append(white, g.Tags["White"])
+		}
+		if strings.Join(white, ",") != strings.Join(test.white, ",") {
+			t.Errorf("%s: got games %v, want %v", test.name, white, test.white)
+		}
+
+		var indexes, lines []int
+		for _, e := range gameErrs {
+			indexes = append(indexes, e.Index)
+			lines = append(lines, e.Line)
+		}
+		if !equalInts(indexes, test.errors) || !equalInts(lines, test.lines) {
+			t.Errorf("%s: got errors for games %v on lines %v, want %v on lines %v", test.name, indexes, lines, test.errors, test.lines)
+		}
+	}
+}
+
+func TestParseStopsAtError(t *testing.T) {
+	input := "[White \"A\"]\n\n1. e4 ) *\n\n[White \"B\"]\n\n1. d4 *\n"
+	games, err := Parse(strings.NewReader(input))
+	if err == nil {
+		t.Fatalf("Expected an error, got %d games", len(games))
+	}
+	if perr, ok := err.(*ParseError); !ok || perr.Line != 3 {
+		t.Errorf("Got error %v, want a parse error on line 3", err)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
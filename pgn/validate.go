@@ -0,0 +1,55 @@
+package pgn
+
+import (
+	"io"
+	"sort"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// A Report describes what is wrong with one game of a PGN file.
+type Report struct {
+	Index    int               // index of the game in the file, starting at 0
+	Tags     map[string]string // tags of the game, if it could be parsed
+	Err      *GameError        // syntax error, if the game could not be parsed
+	Problems []chess.Problem   // problems found by replaying the game
+}
+
+// Validate parses every game from input and replays it, returning
+// a report for each game that has a syntax error or a problem like
+// an illegal or ambiguous move. It also returns the total number of
+// games. The error is only for failures reading the input.
+func Validate(input io.Reader) ([]Report, int, error) {
+	var reports []Report
+	var count int
+
+	reader := NewReader(input)
+	reader.Lenient = true
+
+	for {
+		game, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return reports, count, err
+		}
+
+		// The reader counts the skipped games too
+		index := count + len(reader.Errors)
+		count++
+
+		problems := game.Validate()
+		if len(problems) > 0 {
+			reports = append(reports, Report{Index: index, Tags: game.Tags, Problems: problems})
+		}
+	}
+
+	for _, gameErr := range reader.Errors {
+		reports = append(reports, Report{Index: gameErr.Index, Err: gameErr})
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Index < reports[j].Index
+	})
+
+	return reports, count + len(reader.Errors), nil
+}
@@ -0,0 +1,78 @@
+package pgn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+func TestValidate(t *testing.T) {
+	input := `[Event "Good"]
+
+1. e4 e5 2. Nf3 Nc6 *
+
+[Event "Illegal"]
+
+1. e4 e5 2. Ke3 *
+
+[Event "Ambiguous"]
+
+1. d4 d5 2. Nf3 Nf6 3. Nd2 *
+
+[Event "No check marker"]
+
+1. e4 f5 2. Qh5 *
+
+[Event "Wrong result"]
+[Result "1-0"]
+
+1. f3 e5 2. g4 Qh4# 1-0
+
+[Event "Syntax"]
+
+1. e4 ) *
+`
+
+	reports, count, err := Validate(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 6 {
+		t.Errorf("Got %d games, want 6", count)
+	}
+
+	want := []struct {
+		index int
+		kind  chess.ProblemKind // 0 for a syntax error
+		ply   int
+	}{
+		{1, chess.IllegalMove, 2},
+		{2, chess.AmbiguousMove, 4},
+		{3, chess.WrongCheckMarker, 2},
+		{4, chess.WrongResult, -1},
+		{5, 0, 0},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("Got %d reports, want %d: %+v", len(reports), len(want), reports)
+	}
+	for i, w := range want {
+		r := reports[i]
+		if r.Index != w.index {
+			t.Errorf("Report %d: got game %d, want %d", i, r.Index, w.index)
+		}
+		if w.kind == 0 {
+			if r.Err == nil || len(r.Problems) > 0 {
+				t.Errorf("Game %d: got %+v, want only a syntax error", r.Index, r)
+			}
+			continue
+		}
+		if r.Err != nil || len(r.Problems) != 1 {
+			t.Errorf("Game %d: got %+v, want one problem", r.Index, r)
+			continue
+		}
+		if p := r.Problems[0]; p.Kind != w.kind || p.Ply != w.ply {
+			t.Errorf("Game %d: got problem %v (kind %d, ply %d), want kind %d at ply %d", r.Index, p, p.Kind, p.Ply, w.kind, w.ply)
+		}
+	}
+}
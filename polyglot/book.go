@@ -0,0 +1,206 @@
+package polyglot
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// Open opens the Polyglot book at path. The book is not read
+// into memory; each lookup is a binary search of the file.
+func Open(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	book, err := NewBook(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	book.closer = f
+
+	return book, nil
+}
+
+// NewBook returns a Book that reads entries from r, which
+// holds size bytes of book data.
+func NewBook(r io.ReaderAt, size int64) (*Book, error) {
+	if size%entrySize != 0 {
+		return nil, errors.New("Polyglot book size is not a multiple of 16 bytes")
+	}
+	return &Book{r: r, n: size / entrySize}, nil
+}
+
+// A Book is a Polyglot opening book.
+type Book struct {
+	r      io.ReaderAt
+	n      int64 // number of entries
+	closer io.Closer
+}
+
+// Close closes the book's file, if it was opened with Open.
+func (b *Book) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+	return b.closer.Close()
+}
+
+// Entries returns the entries for the position with the given
+// key, in the order they are in the book, which is usually by
+// weight from highest to lowest.
+func (b *Book) Entries(key uint64) ([]Entry, error) {
+	// Find the first entry with the key
+	var readErr error
+	first := sort.Search(int(b.n), func(i int) bool {
+		e, err := b.entry(int64(i))
+		if err != nil {
+			readErr = err
+			return true
+		}
+		return e.Key >= key
+	})
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	var entries []Entry
+	for i := int64(first); i < b.n; i++ {
+		e, err := b.entry(i)
+		if err != nil {
+			return entries, err
+		}
+		if e.Key != key {
+			break
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Moves returns the book moves for the position, from the
+// highest weight to the lowest. Moves with weight 0 are
+// left out, as Polyglot does.
+func (b *Book) Moves(pos chess.Position) ([]BookMove, error) {
+	entries, err := b.Entries(Key(pos))
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	for _, e := range entries {
+		total += int(e.Weight)
+	}
+
+	var moves []BookMove
+	for _, e := range entries {
+		if e.Weight == 0 {
+			continue
+		}
+		m, err := DecodeMove(pos, e.Move)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, BookMove{
+			Move:   m,
+			Weight: int(e.Weight),
+			Share:  float64(e.Weight) / float64(total),
+			Learn:  e.Learn,
+		})
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Weight > moves[j].Weight
+	})
+
+	return moves, nil
+}
+
+// LeavesBook returns the index of the first move of the game
+// that is not in the book, or -1 if every move is. A move with
+// weight 0 is counted as out of book.
+func (b *Book) LeavesBook(game chess.Game) (int, error) {
+	ply := -1
+
+	err := game.Replay(func(pos chess.Position, m chess.PlayedMove) error {
+		moves, err := b.Moves(pos)
+		if err != nil {
+			return err
+		}
+		for _, bm := range moves {
+			if bm.Move.From == m.From && bm.Move.To == m.To && bm.Move.Promotion == m.Promotion {
+				return nil
+			}
+		}
+		ply = m.Ply
+		return errLeftBook
+	})
+	if err != nil && err != errLeftBook {
+		return -1, err
+	}
+
+	return ply, nil
+}
+
+// entry reads the entry at index i.
+func (b *Book) entry(i int64) (Entry, error) {
+	var buf [entrySize]byte
+	_, err := b.r.ReadAt(buf[:], i*entrySize)
+	if err != nil {
+		return Entry{}, err
+	}
+	return decodeEntry(buf[:]), nil
+}
+
+// An Entry is one record of a Polyglot book.
+type Entry struct {
+	Key    uint64 // key of the position; see Key
+	Move   uint16 // the move; see EncodeMove
+	Weight uint16 // how often the move should be played, relative to the others
+	Learn  uint32 // used by some programs to record learning
+}
+
+// A BookMove is a move the book gives for a position.
+type BookMove struct {
+	Move   chess.PlayedMove
+	Weight int
+	Share  float64 // the move's weight as a fraction of the position's total
+	Learn  uint32
+}
+
+// decodeEntry decodes an entry from 16 big-endian bytes.
+func decodeEntry(b []byte) Entry {
+	return Entry{
+		Key:    binary.BigEndian.Uint64(b[0:8]),
+		Move:   binary.BigEndian.Uint16(b[8:10]),
+		Weight: binary.BigEndian.Uint16(b[10:12]),
+		Learn:  binary.BigEndian.Uint32(b[12:16]),
+	}
+}
+
+// encodeEntry encodes an entry into 16 big-endian bytes.
+func encodeEntry(b []byte, e Entry) {
+	binary.BigEndian.PutUint64(b[0:8], e.Key)
+	binary.BigEndian.PutUint16(b[8:10], e.Move)
+	binary.BigEndian.PutUint16(b[10:12], e.Weight)
+	binary.BigEndian.PutUint32(b[12:16], e.Learn)
+}
+
+// errLeftBook stops replaying a game once it leaves the book.
+var errLeftBook = errors.New("Left the book")
+
+// entrySize is the size of an entry in bytes.
+const entrySize = 16
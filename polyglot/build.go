@@ -0,0 +1,203 @@
+package polyglot
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// BuildOptions configures how a Builder makes a book.
+type BuildOptions struct {
+	// MaxPly is how many moves into each game are
+	// added to the book. If zero, it is 30.
+	MaxPly int
+
+	// MinGames is how many games a move must be played in
+	// to be kept in the book. If zero, it is 1.
+	MinGames int
+
+	// Color, if not chess.NoColor, limits the book to the
+	// moves of one player, to make a book for that color.
+	Color chess.Color
+
+	// Each game's moves count toward their weight by the
+	// game's result, from the point of view of the player
+	// making them. Polyglot uses 2 for a win, 1 for a draw,
+	// and 0 for a loss. None may be negative, and at least
+	// one must be more than zero.
+	WinWeight, DrawWeight, LossWeight float64
+
+	// MinElo, if set, leaves out the moves of players rated
+	// below it, and of unrated players.
+	MinElo int
+
+	// If EloWeighting is true, each move's weight is also in
+	// proportion to the rating of the player making it, where
+	// a rating of 2000 counts as 1. Unrated players count as 1.
+	EloWeighting bool
+}
+
+// NewBuilder returns a Builder that makes a book with opts.
+// It returns an error if the result weights can't be used.
+func NewBuilder(opts BuildOptions) (*Builder, error) {
+	if opts.WinWeight < 0 || opts.DrawWeight < 0 || opts.LossWeight < 0 {
+		return nil, errors.New("Move weights can't be negative")
+	}
+	if opts.WinWeight == 0 && opts.DrawWeight == 0 && opts.LossWeight == 0 {
+		return nil, errors.New("At least one move weight must be more than zero")
+	}
+	if opts.MaxPly == 0 {
+		opts.MaxPly = 30
+	}
+	if opts.MinGames == 0 {
+		opts.MinGames = 1
+	}
+	return &Builder{
+		opts:      opts,
+		positions: make(map[uint64]map[uint16]*moveStats),
+	}, nil
+}
+
+// A Builder makes a Polyglot book from the moves of games.
+type Builder struct {
+	opts      BuildOptions
+	positions map[uint64]map[uint16]*moveStats // by position key, then move
+}
+
+// moveStats accumulates the games a move was played in.
+type moveStats struct {
+	games  int
+	weight float64 // not rounded until the entries are made
+}
+
+// Add adds the moves of a game to the book. Games without
+// a result are skipped, since their moves can't be weighed.
+// An error means the game could not be replayed; any moves
+// before the bad one are still added.
+func (b *Builder) Add(game chess.Game) error {
+	result, err := game.Result()
+	if err != nil || result == chess.ResultUnknown {
+		return nil
+	}
+
+	white, werr := game.WhiteElo()
+	black, berr := game.BlackElo()
+	if werr != nil {
+		white = 0
+	}
+	if berr != nil {
+		black = 0
+	}
+
+	err = game.Replay(func(pos chess.Position, m chess.PlayedMove) error {
+		if m.Ply >= b.opts.MaxPly {
+			return errMaxPly
+		}
+		if b.opts.Color != chess.NoColor && pos.ToMove != b.opts.Color {
+			return nil
+		}
+
+		elo := white
+		if pos.ToMove == chess.BlackTeam {
+			elo = black
+		}
+		if b.opts.MinElo > 0 && elo < b.opts.MinElo {
+			return nil
+		}
+
+		weight := b.opts.LossWeight
+		switch result.Points(pos.ToMove) {
+		case 1:
+			weight = b.opts.WinWeight
+		case 0.5:
+			weight = b.opts.DrawWeight
+		}
+		if b.opts.EloWeighting && elo > 0 {
+			weight *= float64(elo) / 2000
+		}
+
+		key := Key(pos)
+		moves, ok := b.positions[key]
+		if !ok {
+			moves = make(map[uint16]*moveStats)
+			b.positions[key] = moves
+		}
+		move := EncodeMove(m)
+		stats, ok := moves[move]
+		if !ok {
+			stats = new(moveStats)
+			moves[move] = stats
+		}
+		stats.games++
+		stats.weight += weight
+
+		return nil
+	})
+	if err == errMaxPly {
+		return nil
+	}
+	return err
+}
+
+// Entries returns the entries of the book, sorted by key and then
+// from the highest weight to the lowest, as the book file requires.
+// Moves played in too few games or with no weight are left out.
+// The weights of each position are scaled so that the highest is
+// the largest that fits in 16 bits, keeping their proportions.
+func (b *Builder) Entries() []Entry {
+	var entries []Entry
+
+	for key, moves := range b.positions {
+		var kept []Entry
+		var max float64
+		for move, stats := range moves {
+			if stats.games < b.opts.MinGames || stats.weight <= 0 {
+				continue
+			}
+			max = math.Max(max, stats.weight)
+			kept = append(kept, Entry{Key: key, Move: move})
+		}
+
+		scale := math.MaxUint16 / max
+		for i := range kept {
+			w := math.Round(moves[kept[i].Move].weight * scale)
+			kept[i].Weight = uint16(math.Max(w, 1))
+		}
+
+		entries = append(entries, kept...)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		if entries[i].Weight != entries[j].Weight {
+			return entries[i].Weight > entries[j].Weight
+		}
+		return entries[i].Move < entries[j].Move
+	})
+
+	return entries
+}
+
+// WriteBook writes entries to w in the Polyglot format. The
+// entries must already be sorted, as by Builder.Entries.
+func WriteBook(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	var buf [entrySize]byte
+	for _, e := range entries {
+		encodeEntry(buf[:], e)
+		_, err := bw.Write(buf[:])
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// errMaxPly stops replaying a game at the book's maximum ply.
+var errMaxPly = errors.New("Reached the maximum ply")
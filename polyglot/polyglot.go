@@ -0,0 +1,155 @@
+// Package polyglot reads and writes opening books in the Polyglot
+// format, which is a sorted file of entries that map the hash key
+// of a position to a move and its weight.
+package polyglot
+
+import (
+	"errors"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// Key returns the Polyglot hash key of a position. Positions
+// that are the same have the same key, however they came about.
+// The en passant file only counts if a pawn can capture there.
+func Key(pos chess.Position) uint64 {
+	var key uint64
+
+	for row := 0; row < chess.Size; row++ {
+		for col := 0; col < chess.Size; col++ {
+			piece := pos.Board.Spaces[row][col]
+			if piece.Rank == chess.Empty {
+				continue
+			}
+			kind := 2 * pieceKinds[piece.Rank]
+			if piece.Color == chess.WhiteTeam {
+				kind++
+			}
+			key ^= random64[64*kind+8*row+col]
+		}
+	}
+
+	for i, right := range castleRights {
+		if pos.Board.Castling&right != 0 {
+			key ^= random64[randomCastle+i]
+		}
+	}
+
+	if target, ok := pos.EnPassantTarget(); ok && canCaptureEnPassant(pos, target) {
+		key ^= random64[randomEnPassant+target.Col]
+	}
+
+	if pos.ToMove == chess.WhiteTeam {
+		key ^= random64[randomTurn]
+	}
+
+	return key
+}
+
+// canCaptureEnPassant returns whether a pawn of the player
+// to move stands next to the pawn that passed over target.
+func canCaptureEnPassant(pos chess.Position, target chess.Coord) bool {
+	row := target.Row - 1
+	if pos.ToMove == chess.BlackTeam {
+		row = target.Row + 1
+	}
+	for _, col := range []int{target.Col - 1, target.Col + 1} {
+		if col < 0 || col >= chess.Size {
+			continue
+		}
+		piece := pos.Board.Spaces[row][col]
+		if piece.Rank == chess.Pawn && piece.Color == pos.ToMove {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeMove returns the Polyglot encoding of a move: the
+// destination square in bits 0-5, the origin in bits 6-11,
+// and the promotion piece in bits 12-14. Castling is encoded
+// as the king capturing its own rook.
+func EncodeMove(m chess.PlayedMove) uint16 {
+	to := m.To
+	switch m.Castle {
+	case chess.KingsideCastle:
+		to.Col = 7
+	case chess.QueensideCastle:
+		to.Col = 0
+	}
+
+	move := uint16(to.Col) | uint16(to.Row)<<3 | uint16(m.From.Col)<<6 | uint16(m.From.Row)<<9
+	for i, rank := range promotions {
+		if rank == m.Promotion && rank != chess.Empty {
+			move |= uint16(i) << 12
+		}
+	}
+	return move
+}
+
+// DecodeMove decodes a move for the position pos, in which
+// it is played. The position is needed to tell castling
+// apart from a king capturing on its rook's square.
+func DecodeMove(pos chess.Position, move uint16) (chess.PlayedMove, error) {
+	var m chess.PlayedMove
+
+	m.To = chess.Coord{Row: int(move>>3) & 7, Col: int(move) & 7}
+	m.From = chess.Coord{Row: int(move>>9) & 7, Col: int(move>>6) & 7}
+
+	promotion := int(move>>12) & 7
+	if promotion >= len(promotions) {
+		return m, errors.New("Bad promotion in book move")
+	}
+	m.Promotion = promotions[promotion]
+
+	piece := pos.Board.Spaces[m.From.Row][m.From.Col]
+	if piece.Rank == chess.Empty {
+		return m, errors.New("Book move " + m.UCI() + " doesn't start on a piece")
+	}
+
+	target := pos.Board.Spaces[m.To.Row][m.To.Col]
+	if piece.Rank == chess.King && target.Rank == chess.Rook && target.Color == piece.Color {
+		if m.To.Col > m.From.Col {
+			m.Castle, m.To.Col = chess.KingsideCastle, m.From.Col+2
+		} else {
+			m.Castle, m.To.Col = chess.QueensideCastle, m.From.Col-2
+		}
+	}
+
+	if piece.Rank == chess.Pawn && m.From.Col != m.To.Col && target.Rank == chess.Empty {
+		m.EnPassant = true
+	}
+
+	return m, nil
+}
+
+// pieceKinds maps each kind of piece to its index in random64,
+// which is doubled, plus one for White.
+var pieceKinds = map[chess.Rank]int{
+	chess.Pawn:   0,
+	chess.Knight: 1,
+	chess.Bishop: 2,
+	chess.Rook:   3,
+	chess.Queen:  4,
+	chess.King:   5,
+}
+
+// castleRights are the castling rights in the order
+// of their numbers in random64.
+var castleRights = []chess.CastleRights{
+	chess.WhiteKingside,
+	chess.WhiteQueenside,
+	chess.BlackKingside,
+	chess.BlackQueenside,
+}
+
+// promotions are the pieces a pawn may promote to,
+// indexed by their number in an encoded move.
+var promotions = []chess.Rank{chess.Empty, chess.Knight, chess.Bishop, chess.Rook, chess.Queen}
+
+// Offsets of the parts of random64 after the pieces
+const (
+	randomCastle    = 768
+	randomEnPassant = 772
+	randomTurn      = 780
+)
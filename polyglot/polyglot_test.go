@@ -0,0 +1,240 @@
+package polyglot
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mholt/chessml/chess"
+)
+
+// The keys published with the Polyglot book format
+var referenceKeys = []struct {
+	fen string
+	key uint64
+}{
+	{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0x463b96181691fc9c},
+	{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 0x823c9b50fd114196},
+	{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", 0x0756b94461c50fb0},
+	{"rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", 0x662fafb965db29d4},
+	{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 0x22a48b5a8e47ff78},
+	{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", 0x652a607ca3f242c1},
+	{"rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", 0x00fdd303c946bdd9},
+	{"rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", 0x3c8123ea7b067637},
+	{"rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", 0x5c3f9b829b279560},
+}
+
+func TestKey(t *testing.T) {
+	for _, test := range referenceKeys {
+		pos, err := chess.ParseFEN(test.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", test.fen, err)
+		}
+		if got := Key(pos); got != test.key {
+			t.Errorf("%s: got key %016x, want %016x", test.fen, got, test.key)
+		}
+	}
+}
+
+func TestKeyAfterMoves(t *testing.T) {
+	for _, test := range []struct {
+		moves string
+		keys  []uint64 // after each move
+	}{
+		{"e4 d5 e5 f5 Ke2 Kf7", []uint64{
+			0x823c9b50fd114196,
+			0x0756b94461c50fb0,
+			0x662fafb965db29d4,
+			0x22a48b5a8e47ff78,
+			0x652a607ca3f242c1,
+			0x00fdd303c946bdd9,
+		}},
+		{"a4 b5 h4 b4 c4 bxc3 Ra3", []uint64{
+			4: 0x3c8123ea7b067637,
+			6: 0x5c3f9b829b279560,
+		}},
+	} {
+		game := newGame(test.moves)
+		for ply := range game.Moves {
+			if err := game.Execute(1); err != nil {
+				t.Fatalf("%s: %v", test.moves, err)
+			}
+			want := test.keys[ply]
+			if want == 0 {
+				continue // no published key
+			}
+			if got := Key(game.Position()); got != want {
+				t.Errorf("%s, after move %d: got key %016x, want %016x", test.moves, ply+1, got, want)
+			}
+		}
+	}
+}
+
+func TestEncodeDecodeMove(t *testing.T) {
+	for _, test := range []struct {
+		fen  string
+		move chess.PlayedMove
+		want uint16
+	}{
+		{
+			fen:  chess.StartFEN,
+			move: chess.PlayedMove{From: sq("e2"), To: sq("e4")},
+			want: 0x031c,
+		},
+		{
+			fen:  "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			move: chess.PlayedMove{From: sq("e1"), To: sq("g1"), Castle: chess.KingsideCastle},
+			want: 0x0107,
+		},
+		{
+			fen:  "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+			move: chess.PlayedMove{From: sq("e8"), To: sq("c8"), Castle: chess.QueensideCastle},
+			want: 0x0f38,
+		},
+		{
+			fen:  "8/4P3/8/8/8/8/8/k6K w - - 0 1",
+			move: chess.PlayedMove{From: sq("e7"), To: sq("e8"), Promotion: chess.Queen},
+			want: 0x4d3c,
+		},
+		{
+			fen:  "8/4P3/8/8/8/8/8/k6K w - - 0 1",
+			move: chess.PlayedMove{From: sq("e7"), To: sq("e8"), Promotion: chess.Knight},
+			want: 0x1d3c,
+		},
+		{
+			fen:  "k7/8/8/3pP3/8/8/8/7K w - d6 0 1",
+			move: chess.PlayedMove{From: sq("e5"), To: sq("d6"), EnPassant: true},
+			want: 0x092b,
+		},
+	} {
+		pos, err := chess.ParseFEN(test.fen)
+		if err != nil {
+			t.Fatalf("%s: %v", test.fen, err)
+		}
+		move := EncodeMove(test.move)
+		if move != test.want {
+			t.Errorf("%s %s: encoded as %04x, want %04x", test.fen, test.move.UCI(), move, test.want)
+		}
+		got, err := DecodeMove(pos, move)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.fen, test.move.UCI(), err)
+			continue
+		}
+		if got != test.move {
+			t.Errorf("%s %s: decoded as %+v, want %+v", test.fen, test.move.UCI(), got, test.move)
+		}
+	}
+}
+
+func TestBookRoundTrip(t *testing.T) {
+	builder, err := NewBuilder(BuildOptions{MaxPly: 2, WinWeight: 2, DrawWeight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		moves  string
+		result string
+	}{
+		{"e4 e5", chess.Draw},
+		{"e4 c5", chess.WhiteWin},
+		{"d4 d5", chess.BlackWin},
+	} {
+		game := newGame(test.moves)
+		game.Tags["Result"] = test.result
+		if err := builder.Add(game); err != nil {
+			t.Fatalf("%s: %v", test.moves, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBook(&buf, builder.Entries()); err != nil {
+		t.Fatal(err)
+	}
+	book, err := NewBook(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start, _ := chess.ParseFEN(chess.StartFEN)
+	moves, err := book.Moves(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range moves {
+		got = append(got, m.Move.UCI())
+	}
+	// e4 drew once and won once; d4 lost, so it has no weight
+	if strings.Join(got, " ") != "e2e4" {
+		t.Errorf("Got book moves %v from the start, want [e2e4]", got)
+	}
+
+	game := newGame("e4 e5 Nf3")
+	ply, err := book.LeavesBook(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ply != 2 {
+		t.Errorf("Game left the book at ply %d, want 2", ply)
+	}
+}
+
+func TestBuilderWeights(t *testing.T) {
+	builder, err := NewBuilder(BuildOptions{MaxPly: 1, WinWeight: 2, DrawWeight: 1, EloWeighting: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		moves string
+		elo   string
+	}{
+		{"e4", "1400"},
+		{"d4", "2800"},
+	} {
+		game := newGame(test.moves)
+		game.Tags["Result"] = chess.WhiteWin
+		game.Tags["WhiteElo"] = test.elo
+		if err := builder.Add(game); err != nil {
+			t.Fatalf("%s: %v", test.moves, err)
+		}
+	}
+
+	weights := make(map[uint16]uint16)
+	for _, e := range builder.Entries() {
+		weights[e.Move] = e.Weight
+	}
+	e4 := EncodeMove(chess.PlayedMove{From: sq("e2"), To: sq("e4")})
+	d4 := EncodeMove(chess.PlayedMove{From: sq("d2"), To: sq("d4")})
+	if weights[d4] != 65535 || weights[e4] != 32768 {
+		t.Errorf("Got weights %d for e4 and %d for d4, want 32768 and 65535", weights[e4], weights[d4])
+	}
+
+	for _, opts := range []BuildOptions{
+		{},
+		{WinWeight: -1, DrawWeight: 1},
+	} {
+		if _, err := NewBuilder(opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
+
+// newGame makes a game of the moves, in SAN separated by spaces,
+// from the standard starting position.
+func newGame(moves string) chess.Game {
+	game := chess.Game{Tags: make(map[string]string)}
+	for i, text := range strings.Fields(moves) {
+		color := chess.WhiteTeam
+		if i%2 == 1 {
+			color = chess.BlackTeam
+		}
+		game.Moves = append(game.Moves, chess.Move{PlayerColor: color, Number: i/2 + 1, Text: text})
+	}
+	game.Reset()
+	return game
+}
+
+// sq returns the coordinate of a square like "e4".
+func sq(notation string) chess.Coord {
+	return chess.NotationToCoord(notation)
+}